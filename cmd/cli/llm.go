@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+)
+
+// defaultProvider prefers the local endpoint, so content is not sent to
+// third parties, then OpenRouter and OpenAI.
+func defaultProvider(config WallabagTelegramConfig) string {
+	switch {
+	case config.LocalLLMBaseURL != "":
+		return llm.ProviderLocal
+	case config.OpenrouterApiKey != "":
+		return llm.ProviderOpenRouter
+	case config.OpenAISecretKey != "":
+		return llm.ProviderOpenAI
+	}
+	return ""
+}
+
+func llmOptions(config WallabagTelegramConfig, provider string) llm.Options {
+	opts := llm.Options{
+		Temperature: config.LLMTemperature,
+		MaxTokens:   config.LLMMaxTokens,
+		Timeout:     config.LLMTimeout,
+	}
+	switch provider {
+	case llm.ProviderLocal:
+		opts.BaseURL = config.LocalLLMBaseURL
+		opts.Model = config.LocalLLMModel
+		// servers like Ollama run models with a smaller context than they support
+		opts.ContextWindow = config.LocalLLMContextSize
+	case llm.ProviderOpenRouter:
+		opts.APIKey = config.OpenrouterApiKey
+		opts.Model = config.OpenrouterModel
+		opts.Proxy = config.OpenAIProxyUrl
+	case llm.ProviderOpenAI:
+		opts.APIKey = config.OpenAISecretKey
+		opts.Model = config.OpenAIModel
+		opts.Proxy = config.OpenAIProxyUrl
+	}
+	return opts
+}
+
+// newChatCompleter returns nil completer when no provider is configured.
+// Providers listed in llm_fallback are tried in order, otherwise a single
+// provider is used. Either way transient errors are retried.
+func newChatCompleter(config WallabagTelegramConfig) (llm.ChatCompleter, error) {
+	names := config.LLMFallback
+	if len(names) == 0 {
+		provider := config.LLMProvider
+		if provider == "" {
+			provider = defaultProvider(config)
+		}
+		if provider == "" {
+			return nil, nil
+		}
+		names = []string{provider}
+	}
+	providers := make([]llm.Provider, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		cl, err := llm.New(name, llmOptions(config, name))
+		if err != nil {
+			return nil, fmt.Errorf("llm provider %s: %w", name, err)
+		}
+		providers = append(providers, llm.Provider{Name: name, ChatCompleter: cl})
+	}
+	return llm.NewFallback(providers, config.LLMRetry, config.LLMBreaker), nil
+}
+
+// newEmbedder returns nil embedder unless embeddings_provider is set,
+// it is opt-in as the first sync embeds the whole library.
+func newEmbedder(config WallabagTelegramConfig) (llm.Embedder, error) {
+	provider := config.EmbeddingsProvider
+	if provider == "" {
+		return nil, nil
+	}
+	opts := llmOptions(config, provider)
+	opts.EmbeddingModel = config.EmbeddingModel
+	return llm.NewEmbedder(provider, opts)
+}
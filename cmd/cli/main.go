@@ -1,38 +1,83 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/viper"
 
 	logrus "github.com/sirupsen/logrus"
 
+	"github.com/vanadium23/wallabag-telegram-bot/internal/accounting"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/bot"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/embeddings"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/qa"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/reader"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/rules"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/summarization"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/tagging"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/telegraph"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/urlnorm"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
 )
 
 type WallabagTelegramConfig struct {
-	TelegramToken        string   `json:"token"`
-	WallabagSite         string   `json:"wallabag_site"`
-	WallabagClientID     string   `json:"client_id"`
-	WallabagClientSecret string   `json:"client_secret"`
-	WallabagUsername     string   `json:"username"`
-	WallabagPassword     string   `json:"password"`
-	WallabagDefaultTags  string   `json:"default_tags"`
-	TelegramAllowedUsers []string `json:"filter_users"`
-	OpenAISecretKey      string   `json:"open_ai_secret_key"`
-	OpenAIProxyUrl       *url.URL `json:"open_ai_proxy_url"`
-	OpenrouterApiKey     string   `json:"openrouter_api_key"`
-	OpenrouterModel      string   `json:"openrouter_model"`
+	TelegramToken        string
+	WallabagSite         string
+	WallabagClientID     string
+	WallabagClientSecret string
+	WallabagUsername     string
+	WallabagPassword     string
+	WallabagDefaultTags  string
+	WallabagTimeout      time.Duration
+	RequestTimeout       time.Duration
+	TelegramAllowedUsers []string
+	OpenAISecretKey      string
+	OpenAIProxyUrl       *url.URL
+	OpenrouterApiKey     string
+	OpenrouterModel      string
+	LocalLLMBaseURL      string
+	LocalLLMModel        string
+	OpenAIModel          string
+	LLMProvider          string
+	LLMTemperature       float32
+	LLMMaxTokens         int
+	LLMTimeout           time.Duration
+	LocalLLMContextSize  int
+	LLMFallback          []string
+	LLMRetry             llm.RetryPolicy
+	LLMBreaker           llm.BreakerPolicy
+	LLMPrices            accounting.Prices
+	LLMMonthlyBudget     float64
+	Taxonomy             tagging.Taxonomy
+	LLMKeepCaptions      bool
+	SummaryStorage       string
+	EmbeddingsProvider   string
+	EmbeddingModel       string
+	EmbeddingsSync       time.Duration
+	TagCacheTTL          time.Duration
+	TagCandidates        int
+	StoragePath          string
+	EmbeddingsPath       string
+	TelegraphToken       string
+	TelegraphAuthorName  string
+	URLRules             urlnorm.Rules
+	Rules                []rules.Rule
+	ReadingTimeBuckets   usecase.ReadingTimeBuckets
 }
 
 func readConfig() (WallabagTelegramConfig, error) {
@@ -84,9 +129,99 @@ func readConfig() (WallabagTelegramConfig, error) {
 
 	FilterUsers := viper.GetStringSlice("filter_users")
 	DefaultTags := viper.GetString("default_tags")
+	viper.SetDefault("wallabag_timeout", 30*time.Second)
+	WallabagTimeout := viper.GetDuration("wallabag_timeout")
+	// summaries of long articles by local models take several requests
+	viper.SetDefault("request_timeout", 10*time.Minute)
+	RequestTimeout := viper.GetDuration("request_timeout")
 	OpenAISecretKey := viper.GetString("openai_secret_key")
 	OpenrouterApiKey := viper.GetString("openrouter_api_key")
 	OpenrouterModel := viper.GetString("openrouter_model")
+	LocalLLMBaseURL := viper.GetString("local_llm_base_url")
+	LocalLLMModel := viper.GetString("local_llm_model")
+	OpenAIModel := viper.GetString("openai_model")
+	LLMProvider := viper.GetString("llm_provider")
+	LLMTemperature := float32(viper.GetFloat64("llm_temperature"))
+	LLMMaxTokens := viper.GetInt("llm_max_tokens")
+	LLMTimeout := viper.GetDuration("llm_timeout")
+	LocalLLMContextSize := viper.GetInt("local_llm_context_window")
+	LLMFallback := viper.GetStringSlice("llm_fallback")
+
+	LLMRetry := llm.DefaultRetryPolicy()
+	if viper.IsSet("llm_retry_attempts") {
+		LLMRetry.Attempts = viper.GetInt("llm_retry_attempts")
+	}
+	LLMBreaker := llm.DefaultBreakerPolicy()
+	if viper.IsSet("llm_breaker_threshold") {
+		LLMBreaker.Threshold = viper.GetInt("llm_breaker_threshold")
+	}
+	if viper.IsSet("llm_breaker_cooldown") {
+		LLMBreaker.Cooldown = viper.GetDuration("llm_breaker_cooldown")
+	}
+
+	LLMPrices, err := accounting.ParsePrices(viper.GetStringSlice("llm_prices"))
+	if err != nil {
+		return c, err
+	}
+	LLMPrices = accounting.DefaultPrices().Merge(LLMPrices)
+	LLMMonthlyBudget := viper.GetFloat64("llm_monthly_budget")
+
+	viper.SetDefault("storage_path", "wallabot_state.json")
+	StoragePath := viper.GetString("storage_path")
+	// vectors are kept apart from the state, which is written on every tap
+	viper.SetDefault("embeddings_storage_path", filepath.Join(filepath.Dir(StoragePath), "wallabot_embeddings.json"))
+	EmbeddingsPath := viper.GetString("embeddings_storage_path")
+	TelegraphToken := viper.GetString("telegraph_access_token")
+	TelegraphAuthorName := viper.GetString("telegraph_author_name")
+
+	URLRules := urlnorm.DefaultRules()
+	URLRules.StripParams = append(URLRules.StripParams, viper.GetStringSlice("url_strip_params")...)
+	URLRules.StripHostPrefixes = append(URLRules.StripHostPrefixes, viper.GetStringSlice("url_strip_host_prefixes")...)
+	URLRules.Shorteners = append(URLRules.Shorteners, viper.GetStringSlice("url_shorteners")...)
+	URLRules.AMPHosts = append(URLRules.AMPHosts, viper.GetStringSlice("url_amp_hosts")...)
+	URLRules.KeepFragment = viper.GetBool("url_keep_fragment")
+
+	ReadingTimeBuckets := usecase.DefaultReadingTimeBuckets()
+	if viper.IsSet("reading_time_short_max") {
+		ReadingTimeBuckets.ShortMax = viper.GetInt("reading_time_short_max")
+	}
+	if viper.IsSet("reading_time_medium_max") {
+		ReadingTimeBuckets.MediumMax = viper.GetInt("reading_time_medium_max")
+	}
+	if ReadingTimeBuckets.ShortMax >= ReadingTimeBuckets.MediumMax {
+		return c, errors.New("reading_time_short_max must be less than reading_time_medium_max")
+	}
+
+	Taxonomy := tagging.DefaultTaxonomy()
+	if path := viper.GetString("taxonomy_path"); path != "" {
+		Taxonomy, err = tagging.LoadTaxonomy(path)
+		if err != nil {
+			return c, err
+		}
+	}
+
+	LLMKeepCaptions := viper.GetBool("llm_keep_captions")
+
+	viper.SetDefault("summary_storage", usecase.SummaryStorageAnnotation)
+	SummaryStorage := viper.GetString("summary_storage")
+	if !slices.Contains(usecase.SummaryStorages, SummaryStorage) {
+		return c, fmt.Errorf("summary_storage must be one of %v", usecase.SummaryStorages)
+	}
+
+	EmbeddingsProvider := viper.GetString("embeddings_provider")
+	EmbeddingModel := viper.GetString("embedding_model")
+	viper.SetDefault("embeddings_sync_interval", time.Hour)
+	EmbeddingsSync := viper.GetDuration("embeddings_sync_interval")
+
+	viper.SetDefault("tag_cache_ttl", time.Hour)
+	TagCacheTTL := viper.GetDuration("tag_cache_ttl")
+	viper.SetDefault("tag_candidates", 50)
+	TagCandidates := viper.GetInt("tag_candidates")
+
+	var Rules []rules.Rule
+	if err := viper.UnmarshalKey("rules", &Rules); err != nil {
+		return c, errors.Join(errors.New("wrong rules format"), err)
+	}
 
 	OpenAIProxyString := viper.GetString("openai_proxy_url")
 	var OpenAIProxyUrl *url.URL
@@ -106,11 +241,41 @@ func readConfig() (WallabagTelegramConfig, error) {
 		WallabagUsername:     Username,
 		WallabagPassword:     Password,
 		WallabagDefaultTags:  DefaultTags,
+		WallabagTimeout:      WallabagTimeout,
+		RequestTimeout:       RequestTimeout,
 		TelegramAllowedUsers: FilterUsers,
 		OpenAISecretKey:      OpenAISecretKey,
 		OpenAIProxyUrl:       OpenAIProxyUrl,
 		OpenrouterApiKey:     OpenrouterApiKey,
 		OpenrouterModel:      OpenrouterModel,
+		LocalLLMBaseURL:      LocalLLMBaseURL,
+		LocalLLMModel:        LocalLLMModel,
+		OpenAIModel:          OpenAIModel,
+		LLMProvider:          LLMProvider,
+		LLMTemperature:       LLMTemperature,
+		LLMMaxTokens:         LLMMaxTokens,
+		LLMTimeout:           LLMTimeout,
+		LocalLLMContextSize:  LocalLLMContextSize,
+		LLMFallback:          LLMFallback,
+		LLMRetry:             LLMRetry,
+		LLMBreaker:           LLMBreaker,
+		LLMPrices:            LLMPrices,
+		LLMMonthlyBudget:     LLMMonthlyBudget,
+		Taxonomy:             Taxonomy,
+		LLMKeepCaptions:      LLMKeepCaptions,
+		SummaryStorage:       SummaryStorage,
+		EmbeddingsProvider:   EmbeddingsProvider,
+		EmbeddingModel:       EmbeddingModel,
+		EmbeddingsSync:       EmbeddingsSync,
+		TagCacheTTL:          TagCacheTTL,
+		TagCandidates:        TagCandidates,
+		StoragePath:          StoragePath,
+		EmbeddingsPath:       EmbeddingsPath,
+		TelegraphToken:       TelegraphToken,
+		TelegraphAuthorName:  TelegraphAuthorName,
+		URLRules:             URLRules,
+		Rules:                Rules,
+		ReadingTimeBuckets:   ReadingTimeBuckets,
 	}, nil
 }
 
@@ -126,8 +291,17 @@ func main() {
 		log.Fatalf("Error found while reading config: %v", err)
 	}
 
+	store, err := storage.NewStore(config.StoragePath)
+	if err != nil {
+		log.Fatalf("Error found while opening storage: %v", err)
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	wallabagClient := wallabag.NewWallabagClient(
-		http.DefaultClient,
+		&http.Client{Timeout: config.WallabagTimeout},
 		config.WallabagSite,
 		config.WallabagClientID,
 		config.WallabagClientSecret,
@@ -136,30 +310,99 @@ func main() {
 		config.WallabagDefaultTags,
 	)
 
-	tagger := tagging.NewTagger(
-		config.OpenAISecretKey,
-		config.OpenAIProxyUrl,
-		config.OpenrouterApiKey,
-		config.OpenrouterModel,
-	)
-	summarizer := summarization.NewSummarizer(
-		config.OpenAISecretKey,
-		config.OpenAIProxyUrl,
-		config.OpenrouterApiKey,
-		config.OpenrouterModel,
+	completer, err := newChatCompleter(config)
+	if err != nil {
+		log.Fatalf("Error found while configuring llm provider: %v", err)
+	}
+	ledger := accounting.NewLedger(store, config.LLMPrices, config.LLMMonthlyBudget)
+	if completer == nil {
+		log.Warn("No llm provider configured, tagging and summaries are disabled")
+	} else {
+		completer = accounting.NewMeter(completer, ledger)
+	}
+	text := extract.Options{KeepCaptions: config.LLMKeepCaptions}
+	tagger := tagging.NewTagger(completer, config.Taxonomy, text)
+	summarizer := summarization.NewSummarizer(completer, text)
+	normalizer := urlnorm.NewNormalizer(
+		config.URLRules,
+		&http.Client{Timeout: 10 * time.Second},
 	)
+	embedder, err := newEmbedder(config)
+	if err != nil {
+		log.Fatalf("Error found while configuring embeddings: %v", err)
+	}
+	var index *embeddings.Index
+	if embedder != nil {
+		vectors, err := storage.NewStore(config.EmbeddingsPath)
+		if err != nil {
+			log.Fatalf("Error found while opening embeddings storage: %v", err)
+		}
+		defer vectors.Close()
+		index = embeddings.NewIndex(embedder, vectors, text)
+	}
+	rulesEngine, err := rules.NewEngine(config.Rules)
+	if err != nil {
+		log.Fatalf("Error found while loading rules: %v", err)
+	}
 	wallabotUseCase := usecase.NewWallabotArticleUseCase(
 		wallabagClient,
 		tagger,
+		normalizer,
+		rulesEngine,
+		config.ReadingTimeBuckets,
+		usecase.NewTagCache(wallabagClient, config.TagCacheTTL, config.TagCandidates, usecase.SplitTags(config.WallabagDefaultTags)),
+		summarizer,
+		config.SummaryStorage,
+		index,
 	)
+	if index != nil && config.EmbeddingsSync > 0 {
+		go syncEmbeddings(ctx, log, wallabotUseCase, config.EmbeddingsSync)
+	}
 	b := bot.StartTelegramBot(
+		ctx,
 		config.TelegramToken,
 		timeOut*time.Second,
+		config.RequestTimeout,
 		config.TelegramAllowedUsers,
 		wallabotUseCase,
-		summarizer,
+		summarization.NewSettings(store),
+		qa.NewAnswerer(completer, store, text),
+		ledger,
+		reader.NewProgress(store),
+		telegraph.NewPublisher(
+			telegraph.NewClient(&http.Client{Timeout: 30 * time.Second}, telegraph.DefaultBaseURL),
+			store,
+			config.TelegraphToken,
+			config.TelegraphAuthorName,
+		),
 	)
-	if b != nil {
-		b.Start()
+	if b == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		log.Info("Shutting down")
+		b.Stop()
+	}()
+	b.Start()
+}
+
+// syncEmbeddings keeps the search index fresh, so /ask doesn't wait for
+// a long sync.
+func syncEmbeddings(ctx context.Context, log *logrus.Logger, wallabotUseCase *usecase.WallabotArticleUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		synced, err := wallabotUseCase.SyncEmbeddings(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Warnf("Error found while syncing embeddings: %v", err)
+		} else if synced > 0 {
+			log.Infof("Embedded %d entries", synced)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
 }
@@ -5,11 +5,12 @@ go 1.22.1
 toolchain go1.23.5
 
 require (
-	github.com/sashabaranov/go-openai v1.15.3
+	github.com/sashabaranov/go-openai v1.32.5
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/viper v1.12.0
-	github.com/wojtess/openrouter-api-go v0.0.0-20250202202952-5d485e9a0ea7
+	golang.org/x/net v0.33.0
 	gopkg.in/telebot.v3 v3.0.0
+	gopkg.in/yaml.v3 v3.0.0
 	mvdan.cc/xurls v1.1.0
 )
 
@@ -26,9 +27,8 @@ require (
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/subosito/gotenv v1.3.0 // indirect
-	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
-	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 	gopkg.in/ini.v1 v1.66.4 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0 // indirect
 )
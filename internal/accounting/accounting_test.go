@@ -0,0 +1,121 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+type fixedCompleter struct {
+	calls int
+}
+
+func (f *fixedCompleter) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	f.calls++
+	return llm.Response{
+		Content: "ok",
+		Model:   "gpt-4o-2024-08-06",
+		Usage:   llm.Usage{PromptTokens: 1000000, CompletionTokens: 100000},
+	}, nil
+}
+
+func newTestLedger(t *testing.T, budget float64) (*Ledger, *time.Time) {
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	l := NewLedger(store, DefaultPrices(), budget)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestPricesCost(t *testing.T) {
+	prices := DefaultPrices()
+	usage := llm.Usage{PromptTokens: 2000000, CompletionTokens: 1000000}
+	if cost := prices.Cost("gpt-4o-mini-2024-07-18", usage); math.Abs(cost-0.9) > 1e-9 {
+		t.Errorf("Unexpected cost %v of gpt-4o-mini", cost)
+	}
+	if cost := prices.Cost("llama3.1", usage); cost != 0 {
+		t.Errorf("Unknown models should be free, got %v", cost)
+	}
+}
+
+func TestParsePrices(t *testing.T) {
+	prices, err := ParsePrices([]string{"llama3.1:70b=0.5/0.75"})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if p := prices["llama3.1:70b"]; p.Prompt != 0.5 || p.Completion != 0.75 {
+		t.Errorf("Unexpected price %v", p)
+	}
+	for _, entry := range []string{"gpt-4o", "gpt-4o=1", "=1/2", "gpt-4o=a/2"} {
+		if _, err := ParsePrices([]string{entry}); err == nil {
+			t.Errorf("Expected error for %q", entry)
+		}
+	}
+}
+
+func TestMeterRecordsUsage(t *testing.T) {
+	ledger, now := newTestLedger(t, 0)
+	cl := &fixedCompleter{}
+	meter := NewMeter(cl, ledger)
+	for i := 0; i < 2; i++ {
+		if _, err := meter.Complete(context.Background(), llm.Request{}); err != nil {
+			t.Fatalf("Unexpected error during %s", err)
+		}
+	}
+	*now = now.AddDate(0, 0, 1)
+	meter.Complete(context.Background(), llm.Request{})
+
+	entries := ledger.Since(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if len(entries) != 2 || entries[0].Day != "2024-04-01" || entries[1].Day != "2024-03-31" {
+		t.Fatalf("Unexpected entries %v", entries)
+	}
+	if e := entries[1]; e.Requests != 2 || e.PromptTokens != 2000000 || math.Abs(e.Cost-7) > 1e-9 {
+		t.Errorf("Unexpected entry %v", e)
+	}
+	// a new month starts from zero
+	if cost := ledger.MonthCost(); math.Abs(cost-3.5) > 1e-9 {
+		t.Errorf("Unexpected month cost %v", cost)
+	}
+}
+
+func TestLedgerDropsOldEntries(t *testing.T) {
+	ledger, now := newTestLedger(t, 0)
+	resp := llm.Response{Model: "gpt-4o", Usage: llm.Usage{PromptTokens: 10}}
+	if err := ledger.Record(resp); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	*now = now.AddDate(0, 0, retentionDays+1)
+	if err := ledger.Record(resp); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	entries := ledger.Since(time.Time{})
+	if len(entries) != 1 || entries[0].Day != now.Format(dayLayout) {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+}
+
+func TestMeterStopsOnBudget(t *testing.T) {
+	ledger, _ := newTestLedger(t, 5)
+	cl := &fixedCompleter{}
+	meter := NewMeter(cl, ledger)
+	if _, err := meter.Complete(context.Background(), llm.Request{}); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if _, err := meter.Complete(context.Background(), llm.Request{}); err != nil {
+		t.Fatalf("Budget is not spent yet, got %s", err)
+	}
+	if _, err := meter.Complete(context.Background(), llm.Request{}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected budget error, got %v", err)
+	}
+	if cl.calls != 2 {
+		t.Errorf("Model should not be called over budget, calls %d", cl.calls)
+	}
+}
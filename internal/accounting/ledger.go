@@ -0,0 +1,123 @@
+package accounting
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+const (
+	usageBucket = "llm_usage"
+	dayLayout   = "2006-01-02"
+	// retentionDays of usage are kept, a bit more than a year
+	retentionDays = 400
+)
+
+// Entry sums usage of a model during a day.
+type Entry struct {
+	Day              string  `json:"day"`
+	Model            string  `json:"model"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// Ledger persists usage per day and model. Cost is calculated when usage
+// is recorded, so changing prices doesn't rewrite the history.
+type Ledger struct {
+	store  *storage.Store
+	prices Prices
+	// Budget in USD per calendar month, zero means no limit
+	budget float64
+
+	mx  sync.Mutex
+	now func() time.Time
+	// pruned is the day old entries were last removed on
+	pruned string
+}
+
+func NewLedger(store *storage.Store, prices Prices, budget float64) *Ledger {
+	return &Ledger{store: store, prices: prices, budget: budget, now: time.Now}
+}
+
+func (l *Ledger) Record(resp llm.Response) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	day := l.now().Format(dayLayout)
+	key := day + "|" + resp.Model
+	entry := Entry{Day: day, Model: resp.Model}
+	if _, err := l.store.Get(usageBucket, key, &entry); err != nil {
+		return err
+	}
+	entry.Requests++
+	entry.PromptTokens += resp.Usage.PromptTokens
+	entry.CompletionTokens += resp.Usage.CompletionTokens
+	entry.Cost += l.prices.Cost(resp.Model, resp.Usage)
+	if err := l.store.Put(usageBucket, key, entry); err != nil {
+		return err
+	}
+	if l.pruned != day {
+		l.pruned = day
+		return l.prune(l.now().AddDate(0, 0, -retentionDays).Format(dayLayout))
+	}
+	return nil
+}
+
+// prune removes entries of days before the given one.
+func (l *Ledger) prune(before string) error {
+	for _, key := range l.store.Keys(usageBucket) {
+		if key >= before {
+			// keys are sorted and start with the day
+			break
+		}
+		if err := l.store.Delete(usageBucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Since returns entries starting from the day of t, newest days first.
+func (l *Ledger) Since(t time.Time) []Entry {
+	from := t.Format(dayLayout)
+	var entries []Entry
+	for _, key := range l.store.Keys(usageBucket) {
+		if key < from {
+			continue
+		}
+		var entry Entry
+		if ok, err := l.store.Get(usageBucket, key, &entry); !ok || err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return strings.Compare(b.Day, a.Day)
+	})
+	return entries
+}
+
+// MonthCost sums cost of the current calendar month.
+func (l *Ledger) MonthCost() float64 {
+	now := l.now()
+	var cost float64
+	for _, entry := range l.Since(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())) {
+		cost += entry.Cost
+	}
+	return cost
+}
+
+func (l *Ledger) Budget() float64 {
+	return l.budget
+}
+
+// Exceeded reports whether the monthly budget is spent.
+func (l *Ledger) Exceeded() bool {
+	return l.budget > 0 && l.MonthCost() >= l.budget
+}
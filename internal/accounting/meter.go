@@ -0,0 +1,42 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+)
+
+// ErrBudgetExceeded is returned instead of calling the model once
+// the monthly budget is spent.
+var ErrBudgetExceeded = errors.New("monthly llm budget is exceeded")
+
+// Meter records usage of every completed request in the ledger.
+type Meter struct {
+	cl     llm.ChatCompleter
+	ledger *Ledger
+}
+
+func NewMeter(cl llm.ChatCompleter, ledger *Ledger) *Meter {
+	return &Meter{cl: cl, ledger: ledger}
+}
+
+func (m *Meter) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	if m.ledger.Exceeded() {
+		return llm.Response{}, ErrBudgetExceeded
+	}
+	resp, err := m.cl.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	if err := m.ledger.Record(resp); err != nil {
+		// the answer is paid already, losing a record is better than losing it
+		log.Printf("error on recording llm usage: %v\n", err)
+	}
+	return resp, nil
+}
+
+func (m *Meter) ContextWindow() int {
+	return llm.WindowOf(m.cl)
+}
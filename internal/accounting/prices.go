@@ -0,0 +1,82 @@
+// Package accounting records tokens spent on language models and their
+// cost, so usage can be reviewed and capped with a monthly budget.
+package accounting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+)
+
+// Price in USD per million tokens.
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Prices by model name prefix, longer prefixes win. Models missing
+// in the table, like local ones, are free.
+type Prices map[string]Price
+
+func DefaultPrices() Prices {
+	return Prices{
+		"gpt-4o":                      {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini":                 {Prompt: 0.15, Completion: 0.6},
+		"gpt-4-turbo":                 {Prompt: 10, Completion: 30},
+		"gpt-4":                       {Prompt: 30, Completion: 60},
+		"gpt-3.5-turbo":               {Prompt: 0.5, Completion: 1.5},
+		"openai/gpt-4o":               {Prompt: 2.5, Completion: 10},
+		"openai/gpt-4o-mini":          {Prompt: 0.15, Completion: 0.6},
+		"anthropic/claude-3.5-sonnet": {Prompt: 3, Completion: 15},
+		"anthropic/claude-3-haiku":    {Prompt: 0.25, Completion: 1.25},
+	}
+}
+
+// ParsePrices reads "model=prompt/completion" entries, e.g. "gpt-4o=2.5/10".
+func ParsePrices(entries []string) (Prices, error) {
+	prices := Prices{}
+	for _, entry := range entries {
+		model, value, ok := strings.Cut(entry, "=")
+		prompt, completion, ok2 := strings.Cut(value, "/")
+		if !ok || !ok2 || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("price %q should look like model=prompt/completion", entry)
+		}
+		var p Price
+		var err error
+		if p.Prompt, err = strconv.ParseFloat(strings.TrimSpace(prompt), 64); err != nil {
+			return nil, fmt.Errorf("price %q: %w", entry, err)
+		}
+		if p.Completion, err = strconv.ParseFloat(strings.TrimSpace(completion), 64); err != nil {
+			return nil, fmt.Errorf("price %q: %w", entry, err)
+		}
+		prices[strings.ToLower(strings.TrimSpace(model))] = p
+	}
+	return prices, nil
+}
+
+// Merge returns prices with overrides applied on top.
+func (p Prices) Merge(overrides Prices) Prices {
+	merged := make(Prices, len(p)+len(overrides))
+	for model, price := range p {
+		merged[model] = price
+	}
+	for model, price := range overrides {
+		merged[model] = price
+	}
+	return merged
+}
+
+// Cost of the usage in USD.
+func (p Prices) Cost(model string, usage llm.Usage) float64 {
+	model = strings.ToLower(model)
+	var price Price
+	matched := ""
+	for prefix, candidate := range p {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			price, matched = candidate, prefix
+		}
+	}
+	return (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1e6
+}
@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	tele "gopkg.in/telebot.v3"
+)
+
+const askCount = 5
+
+func askHandler(wallabotUseCase usecase.ArticleUseCase) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		question := strings.TrimSpace(c.Message().Payload)
+		if question == "" {
+			return c.Send("Usage: /ask <question>, e.g. /ask how to estimate projects")
+		}
+		c.Notify(tele.Typing)
+		articles, err := wallabotUseCase.Search(requestContext(c), question, askCount)
+		if err != nil {
+			log.Printf("Semantic search failed with error: %v", err)
+			return c.Send(fmt.Sprintf("Search failed: %s", describeError(err)))
+		}
+		if len(articles) == 0 {
+			return c.Send("Nothing relevant found")
+		}
+		for _, article := range articles {
+			c.Send(formatArticleMessage(article), formArticleButtons(article))
+		}
+		return nil
+	}
+}
@@ -1,13 +1,20 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/vanadium23/wallabag-telegram-bot/internal/accounting"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/qa"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/reader"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/summarization"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/telegraph"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
 	tele "gopkg.in/telebot.v3"
 	"mvdan.cc/xurls"
@@ -20,6 +27,8 @@ const (
 	rateText      = "rate"
 	unrateText    = "unrate"
 	summarizeText = "summarize"
+
+	contextKey = "context"
 )
 
 func middlewareFilterUser(filterUsers []string) tele.MiddlewareFunc {
@@ -37,13 +46,21 @@ func middlewareFilterUser(filterUsers []string) tele.MiddlewareFunc {
 	}
 }
 
+// StartTelegramBot registers handlers, work started by them is cancelled
+// with ctx and every update is handled within requestTimeout.
 func StartTelegramBot(
+	ctx context.Context,
 	telegramBotToken string,
 	pollInterval time.Duration,
+	requestTimeout time.Duration,
 	filterUsers []string,
 	// for handlers
 	wallabotUseCase usecase.ArticleUseCase,
-	summarizier summarization.Summarizer,
+	summarySettings *summarization.Settings,
+	answerer *qa.Answerer,
+	ledger *accounting.Ledger,
+	progress *reader.Progress,
+	publisher *telegraph.Publisher,
 ) *tele.Bot {
 	pref := tele.Settings{
 		Token:  telegramBotToken,
@@ -58,16 +75,17 @@ func StartTelegramBot(
 
 	// use logger
 	b.Use(middlewareFilterUser(filterUsers))
+	b.Use(middlewareContext(ctx, requestTimeout))
 
 	// handlers
 	b.Handle("/start", func(c tele.Context) error {
 		return c.Send("Welcome to wallabot. Just send me a string, and I will save it.")
 	})
 	b.Handle("/random", func(c tele.Context) error {
-		articles, err := wallabotUseCase.FindRandom(5)
+		articles, err := wallabotUseCase.FindRandom(requestContext(c), 5)
 		if err != nil {
 			log.Printf("Wallabag failed with error: %v", err)
-			return c.Send("Wallabag failed with error: %v", err)
+			return c.Send(fmt.Sprintf("Wallabag failed with error: %s", describeError(err)))
 		}
 		for _, article := range articles {
 			msg := formatArticleMessage(article)
@@ -77,10 +95,10 @@ func StartTelegramBot(
 		return nil
 	})
 	b.Handle("/recent", func(c tele.Context) error {
-		articles, err := wallabotUseCase.FindRecent(5)
+		articles, err := wallabotUseCase.FindRecent(requestContext(c), 5)
 		if err != nil {
 			log.Printf("Wallabag failed with error: %v", err)
-			return c.Send("Wallabag failed with error: %v", err)
+			return c.Send(fmt.Sprintf("Wallabag failed with error: %s", describeError(err)))
 		}
 		for _, article := range articles {
 			msg := formatArticleMessage(article)
@@ -90,10 +108,10 @@ func StartTelegramBot(
 		return nil
 	})
 	b.Handle("/short", func(c tele.Context) error {
-		articles, err := wallabotUseCase.FindShort(5)
+		articles, err := wallabotUseCase.FindShort(requestContext(c), 5)
 		if err != nil {
 			log.Printf("Wallabag failed with error: %v", err)
-			return c.Send("Wallabag failed with error: %v", err)
+			return c.Send(fmt.Sprintf("Wallabag failed with error: %s", describeError(err)))
 		}
 		for _, article := range articles {
 			msg := formatArticleMessage(article)
@@ -103,10 +121,10 @@ func StartTelegramBot(
 		return nil
 	})
 	b.Handle("/stats", func(c tele.Context) error {
-		stats, err := wallabotUseCase.GetStats()
+		stats, err := wallabotUseCase.GetStats(requestContext(c))
 		if err != nil {
 			log.Printf("Wallabag failed with error: %v", err)
-			return c.Send(fmt.Sprintf("Failed to get statistics: %v", err))
+			return c.Send(fmt.Sprintf("Failed to get statistics: %s", describeError(err)))
 		}
 
 		message := fmt.Sprintf(`
@@ -123,19 +141,34 @@ func StartTelegramBot(
 
 		return c.Send(message)
 	})
+	b.Handle("/backfill_reading_time", func(c tele.Context) error {
+		c.Send("Tagging library by reading time, it may take a while")
+		// walking the whole library is not bound by the request timeout
+		updated, err := wallabotUseCase.BackfillReadingTime(ctx)
+		if err != nil {
+			log.Printf("Wallabag failed with error: %v", err)
+			return c.Send(fmt.Sprintf("Backfill stopped after %d entries with error: %s", updated, describeError(err)))
+		}
+		return c.Send(fmt.Sprintf("Backfill finished, %d entries were tagged", updated))
+	})
+	b.Handle("/export", exportHandler(wallabotUseCase))
+	b.Handle("/rules", rulesHandler(wallabotUseCase))
+	b.Handle("/settings", settingsHandler(summarySettings))
+	b.Handle("/ask", askHandler(wallabotUseCase))
+	b.Handle("/usage", usageHandler(ledger))
 	b.Handle(formCallbackQuery(archiveText), func(c tele.Context) error {
 		entryID, err := strconv.ParseInt(c.Callback().Data, 10, 64)
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during archiving entry: %v", err),
+				Text:       fmt.Sprintf("Error during archiving entry: %s", describeError(err)),
 			})
 		}
-		article, err := wallabotUseCase.MarkRead(int(entryID))
+		article, err := wallabotUseCase.MarkRead(requestContext(c), int(entryID))
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during archiving entry: %v", err),
+				Text:       fmt.Sprintf("Error during archiving entry: %s", describeError(err)),
 			})
 		}
 		c.Bot().EditReplyMarkup(c.Update().Callback.Message, formArticleButtons(article))
@@ -149,14 +182,14 @@ func StartTelegramBot(
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during restoring entry: %v", err),
+				Text:       fmt.Sprintf("Error during restoring entry: %s", describeError(err)),
 			})
 		}
-		article, err := wallabotUseCase.MarkUnread(int(entryID))
+		article, err := wallabotUseCase.MarkUnread(requestContext(c), int(entryID))
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during archiving entry: %v", err),
+				Text:       fmt.Sprintf("Error during archiving entry: %s", describeError(err)),
 			})
 		}
 		c.Bot().EditReplyMarkup(c.Update().Callback.Message, formArticleButtons(article))
@@ -170,14 +203,14 @@ func StartTelegramBot(
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during mark as scrolled entry: %v", err),
+				Text:       fmt.Sprintf("Error during mark as scrolled entry: %s", describeError(err)),
 			})
 		}
-		article, err := wallabotUseCase.MarkScrolled(int(entryID))
+		article, err := wallabotUseCase.MarkScrolled(requestContext(c), int(entryID))
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during mark as scrolled entry: %v", err),
+				Text:       fmt.Sprintf("Error during mark as scrolled entry: %s", describeError(err)),
 			})
 		}
 		c.Bot().EditReplyMarkup(c.Update().Callback.Message, formArticleButtons(article))
@@ -198,15 +231,15 @@ func StartTelegramBot(
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during rate entry: %v", err),
+				Text:       fmt.Sprintf("Error during rate entry: %s", describeError(err)),
 			})
 		}
 		ratingTag := parts[1]
-		article, err := wallabotUseCase.AddRating(int(entryID), ratingTag)
+		article, err := wallabotUseCase.AddRating(requestContext(c), int(entryID), ratingTag)
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during rate entry: %v", err),
+				Text:       fmt.Sprintf("Error during rate entry: %s", describeError(err)),
 			})
 		}
 		c.Bot().EditReplyMarkup(c.Update().Callback.Message, formArticleButtons(article))
@@ -220,36 +253,62 @@ func StartTelegramBot(
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during summarize entry: %v", err),
+				Text:       fmt.Sprintf("Error during summarize entry: %s", describeError(err)),
 			})
 		}
-		article, err := wallabotUseCase.FindByID(int(entryID))
-		if err != nil {
+		summary, err := wallabotUseCase.Summarize(requestContext(c), int(entryID), summarySettings.Get(c.Sender().ID))
+		if errors.Is(err, llm.ErrUnavailable) {
+			log.Printf("Error during summarize entry %d: %v", entryID, err)
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during summarize entry: %v", err),
+				Text:       "Summaries are temporarily unavailable, please try again later.",
+			})
+		}
+		if errors.Is(err, accounting.ErrBudgetExceeded) {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       "Monthly LLM budget is spent, summaries are disabled until the next month.",
 			})
 		}
-		summary, err := summarizier.Summarize(article.Title, article.Content)
 		if err != nil {
 			return c.Respond(&tele.CallbackResponse{
 				CallbackID: c.Callback().ID,
-				Text:       fmt.Sprintf("Error during summarize entry: %v", err),
+				Text:       fmt.Sprintf("Error during summarize entry: %s", describeError(err)),
 			})
 		}
 		c.Bot().Send(c.Sender(), fmt.Sprintf("Summary %d: %s", entryID, summary))
 		return nil
 	})
+	b.Handle(formCallbackQuery(settingsText), settingsCallbackHandler(summarySettings))
+	b.Handle(formCallbackQuery(readText), readHandler(wallabotUseCase, progress))
+	b.Handle(formCallbackQuery(pageText), pageHandler(wallabotUseCase, progress))
+	b.Handle(formCallbackQuery(instantViewText), instantViewHandler(wallabotUseCase, publisher))
+	b.Handle(formCallbackQuery(relatedText), relatedHandler(wallabotUseCase))
+	b.Handle(formCallbackQuery(exportText), exportCallbackHandler(wallabotUseCase))
 
 	b.Handle(tele.OnText, func(c tele.Context) error {
+		// replies to cards, summaries and answers are questions about the
+		// entry, unless there are links to save
+		if entryID, ok := repliedEntry(c); ok && !xurls.Strict.MatchString(c.Message().Text) {
+			return answerQuestion(c, wallabotUseCase, answerer, entryID)
+		}
 		c.Send("Received message, finding articles and try to save")
+		seen := map[string]bool{}
 		for _, r := range xurls.Strict.FindAllString(c.Message().Text, -1) {
-			article, err := wallabotUseCase.SaveForLater(r)
+			if seen[r] {
+				continue
+			}
+			seen[r] = true
+			article, err := wallabotUseCase.SaveForLater(requestContext(c), r)
 			if err != nil {
-				c.Send(fmt.Sprintf("Found article %s, but save failed with err: %v", r, err))
+				c.Send(fmt.Sprintf("Found article %s, but save failed with err: %s", r, describeError(err)))
 				continue
 			}
-			c.Send(formatArticleMessage(article), formArticleButtons(article))
+			msg := formatArticleMessage(article)
+			if article.AlreadySaved {
+				msg = formatDuplicateNote(article) + "\n" + msg
+			}
+			c.Send(msg, formArticleButtons(article))
 		}
 		return nil
 	})
@@ -257,6 +316,27 @@ func StartTelegramBot(
 	return b
 }
 
+// middlewareContext gives every update its own deadline, derived from ctx
+// so shutdown cancels handlers in flight.
+func middlewareContext(ctx context.Context, timeout time.Duration) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			requestCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			c.Set(contextKey, requestCtx)
+			return next(c)
+		}
+	}
+}
+
+// requestContext returns context of the update set by middlewareContext.
+func requestContext(c tele.Context) context.Context {
+	if ctx, ok := c.Get(contextKey).(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
+
 // formCallbackQuery generates same string as InlineButton.CallbackUnique from telebot
 func formCallbackQuery(text string) string {
 	return "\f" + text
@@ -285,10 +365,25 @@ func formatArticleMessage(article usecase.WallabotArticle) string {
 	)
 }
 
+func formatDuplicateNote(article usecase.WallabotArticle) string {
+	state := "currently unread"
+	if article.IsRead {
+		state = "currently archived, use 📥 to read it again"
+	}
+	return fmt.Sprintf("Already saved on %s, %s", article.CreatedAt.Format("2006-01-02"), state)
+}
+
 func formArticleButtons(article usecase.WallabotArticle) *tele.ReplyMarkup {
 	entry := strconv.Itoa(article.ID)
 
 	selector := &tele.ReplyMarkup{}
+	// ways to open the article get their own row, so rows fit on mobile
+	openRow := selector.Row(
+		selector.Data("📖", readText, entry),
+		selector.Data("⚡", instantViewText, entry),
+		selector.Data("🔗", relatedText, entry),
+		selector.Data("💾", exportText, entry),
+	)
 	stateRow := selector.Row()
 	stateBtn := tele.Btn{}
 	if !article.IsRead {
@@ -317,6 +412,7 @@ func formArticleButtons(article usecase.WallabotArticle) *tele.ReplyMarkup {
 	}
 
 	selector.Inline(
+		openRow,
 		stateRow,
 		ratingRow,
 	)
@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+// describeError explains failures in chat messages, details of wallabag
+// responses go to the log.
+func describeError(err error) string {
+	var message string
+	switch {
+	case errors.Is(err, wallabag.ErrNotFound):
+		message = "entry was not found in wallabag, it may have been deleted"
+	case errors.Is(err, wallabag.ErrUnauthorized):
+		message = "wallabag rejected bot credentials, check client_id, client_secret, username and password"
+	case errors.Is(err, wallabag.ErrRateLimited):
+		message = "wallabag is limiting requests, please try again in a minute"
+	case errors.Is(err, wallabag.ErrServerError):
+		message = "wallabag is not available right now, please try again later"
+	case errors.Is(err, wallabag.ErrDecode):
+		message = "wallabag returned an unexpected response"
+	case errors.Is(err, usecase.ErrIndexing):
+		message = "the library is being indexed for search, please try again in a few minutes"
+	case errors.Is(err, context.DeadlineExceeded):
+		message = "request took too long, please try again later"
+	default:
+		return err.Error()
+	}
+	log.Printf("Request failed with error: %v", err)
+	return message
+}
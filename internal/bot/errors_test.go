@@ -0,0 +1,26 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+func TestDescribeError(t *testing.T) {
+	notFound := fmt.Errorf("fetching entry: %w", &wallabag.StatusError{
+		Method:     "GET",
+		URL:        "https://wallabag.example.com/api/entries/42.json",
+		StatusCode: http.StatusNotFound,
+		Body:       `{"error":{"code":404}}`,
+	})
+	if got := describeError(notFound); !strings.Contains(got, "not found") || strings.Contains(got, "wallabag.example.com") {
+		t.Errorf("Unexpected message %q", got)
+	}
+	if got := describeError(errors.New("rating invalid")); got != "rating invalid" {
+		t.Errorf("Other errors should be kept, got %q", got)
+	}
+}
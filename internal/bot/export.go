@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	exportText          = "export"
+	defaultExportFormat = "epub"
+	exportBundleSize    = 10
+	exportUsage         = "Usage: /export <entry id> [format] or /export tag:<tag> [format]"
+)
+
+func exportHandler(wallabotUseCase usecase.ArticleUseCase) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		args := c.Args()
+		if len(args) == 0 {
+			return c.Send(exportUsage)
+		}
+		format := defaultExportFormat
+		if len(args) > 1 {
+			format = args[1]
+		}
+
+		var file usecase.ExportedFile
+		var err error
+		if tag, ok := strings.CutPrefix(args[0], "tag:"); ok {
+			file, err = wallabotUseCase.ExportByTag(requestContext(c), tag, format, exportBundleSize)
+		} else {
+			entryID, convErr := strconv.Atoi(args[0])
+			if convErr != nil {
+				return c.Send(exportUsage)
+			}
+			file, err = wallabotUseCase.Export(requestContext(c), entryID, format)
+		}
+		if err != nil {
+			log.Printf("Export failed with error: %v", err)
+			return c.Send(fmt.Sprintf("Export failed with error: %s", describeError(err)))
+		}
+		defer file.Body.Close()
+
+		return c.Send(&tele.Document{
+			File:     tele.FromReader(file.Body),
+			FileName: file.Name,
+		})
+	}
+}
+
+// exportCallbackHandler sends the entry in the default format on 💾 of the card.
+func exportCallbackHandler(wallabotUseCase usecase.ArticleUseCase) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		entryID, err := strconv.Atoi(c.Callback().Data)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Export failed with error: %s", describeError(err)),
+			})
+		}
+		file, err := wallabotUseCase.Export(requestContext(c), entryID, defaultExportFormat)
+		if err != nil {
+			log.Printf("Export failed with error: %v", err)
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Export failed with error: %s", describeError(err)),
+			})
+		}
+		defer file.Body.Close()
+
+		c.Send(&tele.Document{
+			File:     tele.FromReader(file.Body),
+			FileName: file.Name,
+		})
+		return c.Respond(&tele.CallbackResponse{
+			CallbackID: c.Callback().ID,
+		})
+	}
+}
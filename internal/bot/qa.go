@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/accounting"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/qa"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	tele "gopkg.in/telebot.v3"
+)
+
+// entryReference finds entry ID in headers of article cards, summaries and
+// answers, other messages like related lists mention entries too.
+var entryReference = regexp.MustCompile(`(?m)^(?:Article №|Summary |Answer )(\d+)(?::|$)`)
+
+// repliedEntry returns entry of the bot message the user replied to.
+func repliedEntry(c tele.Context) (int, bool) {
+	reply := c.Message().ReplyTo
+	if reply == nil || reply.Sender == nil || reply.Sender.ID != c.Bot().Me.ID {
+		return 0, false
+	}
+	return referencedEntry(reply.Text)
+}
+
+func referencedEntry(text string) (int, bool) {
+	m := entryReference.FindStringSubmatch(text)
+	if m == nil {
+		return 0, false
+	}
+	entryID, err := strconv.Atoi(m[1])
+	return entryID, err == nil
+}
+
+func answerQuestion(c tele.Context, wallabotUseCase usecase.ArticleUseCase, answerer *qa.Answerer, entryID int) error {
+	article, err := wallabotUseCase.FindByID(requestContext(c), entryID)
+	if err != nil {
+		return c.Reply(fmt.Sprintf("Failed to open entry %d: %s", entryID, describeError(err)))
+	}
+	c.Notify(tele.Typing)
+	answer, err := answerer.Ask(requestContext(c), c.Sender().ID, entryID, article.Title, article.Content, c.Message().Text)
+	if errors.Is(err, llm.ErrUnavailable) {
+		log.Printf("Error during answering about entry %d: %v", entryID, err)
+		return c.Reply("Answers are temporarily unavailable, please try again later.")
+	}
+	if errors.Is(err, accounting.ErrBudgetExceeded) {
+		return c.Reply("Monthly LLM budget is spent, answers are disabled until the next month.")
+	}
+	if err != nil && answer == "" {
+		return c.Reply(fmt.Sprintf("Failed to answer: %s", describeError(err)))
+	}
+	if err != nil {
+		log.Printf("Error during saving conversation about entry %d: %v", entryID, err)
+	}
+	return c.Reply(fmt.Sprintf("Answer %d: %s", entryID, answer))
+}
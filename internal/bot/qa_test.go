@@ -0,0 +1,28 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+)
+
+func TestReferencedEntry(t *testing.T) {
+	card := formatArticleMessage(usecase.WallabotArticle{ID: 42, Title: "Go", Url: "https://example.com"})
+	tests := []struct {
+		text     string
+		entryID  int
+		expected bool
+	}{
+		{card, 42, true},
+		{"Summary 42: short text about Article №7", 42, true},
+		{"Answer 42: yes", 42, true},
+		{"🔗 Related to Article №42 (1/2)\n\n№7 Go", 0, false},
+		{"Found article https://example.com/42, but save failed", 0, false},
+	}
+	for _, tt := range tests {
+		entryID, ok := referencedEntry(tt.text)
+		if ok != tt.expected || entryID != tt.entryID {
+			t.Errorf("referencedEntry(%q) = %d, %v, expected %d, %v", tt.text, entryID, ok, tt.entryID, tt.expected)
+		}
+	}
+}
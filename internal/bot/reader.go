@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/reader"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	readText = "read"
+	pageText = "page"
+)
+
+func readHandler(wallabotUseCase usecase.ArticleUseCase, progress *reader.Progress) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		entryID, err := strconv.ParseInt(c.Callback().Data, 10, 64)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during opening entry: %s", describeError(err)),
+			})
+		}
+		article, err := wallabotUseCase.FindByID(requestContext(c), int(entryID))
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during opening entry: %s", describeError(err)),
+			})
+		}
+		pages := reader.Paginate(reader.Render(article.Content), reader.PageLimit)
+		if len(pages) == 0 {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       "Entry has no content to read",
+			})
+		}
+		page := min(progress.Position(c.Sender().ID, article.ID), len(pages)-1)
+		c.Send(formatReaderPage(article, pages, page), formPageButtons(article.ID, page, len(pages)), tele.ModeHTML, tele.NoPreview)
+		return c.Respond(&tele.CallbackResponse{
+			CallbackID: c.Callback().ID,
+		})
+	}
+}
+
+func pageHandler(wallabotUseCase usecase.ArticleUseCase, progress *reader.Progress) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		parts := strings.Split(c.Callback().Data, "|")
+		if len(parts) < 2 {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       "Error during turning page: wrong callback data",
+			})
+		}
+		entryID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during turning page: %s", describeError(err)),
+			})
+		}
+		page, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during turning page: %s", describeError(err)),
+			})
+		}
+		article, err := wallabotUseCase.FindByID(requestContext(c), entryID)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during turning page: %s", describeError(err)),
+			})
+		}
+		pages := reader.Paginate(reader.Render(article.Content), reader.PageLimit)
+		if len(pages) == 0 {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       "Entry has no content to read",
+			})
+		}
+		page = max(0, min(page, len(pages)-1))
+		if err := progress.SetPosition(c.Sender().ID, article.ID, page); err != nil {
+			log.Printf("Failed to save reading position: %v", err)
+		}
+		c.Edit(formatReaderPage(article, pages, page), formPageButtons(article.ID, page, len(pages)), tele.ModeHTML, tele.NoPreview)
+		return c.Respond(&tele.CallbackResponse{
+			CallbackID: c.Callback().ID,
+		})
+	}
+}
+
+func formatReaderPage(article usecase.WallabotArticle, pages []string, page int) string {
+	return fmt.Sprintf("📖 <b>%s</b> (%d/%d)\n\n%s",
+		html.EscapeString(shorten(article.Title, 200)),
+		page+1,
+		len(pages),
+		pages[page],
+	)
+}
+
+func formPageButtons(entryID int, page int, total int) *tele.ReplyMarkup {
+	entry := strconv.Itoa(entryID)
+
+	selector := &tele.ReplyMarkup{}
+	row := selector.Row()
+	if page > 0 {
+		row = append(row, selector.Data("◀", pageText, entry, strconv.Itoa(page-1)))
+	}
+	if page < total-1 {
+		row = append(row, selector.Data("▶", pageText, entry, strconv.Itoa(page+1)))
+	}
+	selector.Inline(row)
+	return selector
+}
+
+func shorten(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	relatedText     = "related"
+	relatedPageSize = 5
+)
+
+// relatedHandler sends related entries on 🔗 of the card and edits
+// the list in place when a page is turned.
+func relatedHandler(wallabotUseCase usecase.ArticleUseCase) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		parts := strings.Split(c.Callback().Data, "|")
+		entryID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during finding related entries: %s", describeError(err)),
+			})
+		}
+		page := 0
+		turned := len(parts) > 1
+		if turned {
+			if page, err = strconv.Atoi(parts[1]); err != nil {
+				return c.Respond(&tele.CallbackResponse{
+					CallbackID: c.Callback().ID,
+					Text:       fmt.Sprintf("Error during finding related entries: %s", describeError(err)),
+				})
+			}
+		}
+		articles, total, err := wallabotUseCase.Related(requestContext(c), entryID, page*relatedPageSize, relatedPageSize)
+		if err != nil {
+			log.Printf("Finding related entries failed with error: %v", err)
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during finding related entries: %s", describeError(err)),
+			})
+		}
+		if total == 0 {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       "Nothing related found",
+			})
+		}
+		pages := (total + relatedPageSize - 1) / relatedPageSize
+		message := formatRelated(entryID, articles, page, pages)
+		buttons := formRelatedButtons(entryID, page, pages)
+		if turned {
+			c.Edit(message, buttons, tele.ModeHTML, tele.NoPreview)
+		} else {
+			c.Send(message, buttons, tele.ModeHTML, tele.NoPreview)
+		}
+		return c.Respond(&tele.CallbackResponse{
+			CallbackID: c.Callback().ID,
+		})
+	}
+}
+
+func formatRelated(entryID int, articles []usecase.WallabotArticle, page int, pages int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔗 Related to Article №%d (%d/%d)\n", entryID, page+1, pages)
+	for _, article := range articles {
+		fmt.Fprintf(&sb, "\n№%d <a href=\"%s\">%s</a>", article.ID,
+			html.EscapeString(article.Url),
+			html.EscapeString(shorten(article.Title, 100)),
+		)
+		if article.ReadingTime > 0 {
+			fmt.Fprintf(&sb, " · %d min", article.ReadingTime)
+		}
+		if article.IsRead {
+			sb.WriteString(" · ✅")
+		}
+	}
+	return sb.String()
+}
+
+func formRelatedButtons(entryID int, page int, pages int) *tele.ReplyMarkup {
+	entry := strconv.Itoa(entryID)
+
+	selector := &tele.ReplyMarkup{}
+	row := selector.Row()
+	if page > 0 {
+		row = append(row, selector.Data("◀", relatedText, entry, strconv.Itoa(page-1)))
+	}
+	if page < pages-1 {
+		row = append(row, selector.Data("▶", relatedText, entry, strconv.Itoa(page+1)))
+	}
+	selector.Inline(row)
+	return selector
+}
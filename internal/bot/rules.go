@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	tele "gopkg.in/telebot.v3"
+)
+
+func rulesHandler(wallabotUseCase usecase.ArticleUseCase) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		args := c.Args()
+		if len(args) == 0 {
+			rules := wallabotUseCase.ListRules()
+			if len(rules) == 0 {
+				return c.Send("No rules configured")
+			}
+			lines := make([]string, len(rules))
+			for i, rule := range rules {
+				lines[i] = fmt.Sprintf("%d. %s", i+1, rule)
+			}
+			return c.Send("📐 Rules\n\n" + strings.Join(lines, "\n") + "\n\nSend /rules <url> to test them")
+		}
+
+		result, err := wallabotUseCase.TestRules(requestContext(c), args[0])
+		if err != nil {
+			log.Printf("Wallabag failed with error: %v", err)
+			return c.Send(fmt.Sprintf("Failed to test rules: %s", describeError(err)))
+		}
+		if len(result.Matched) == 0 {
+			return c.Send("No rules match this link")
+		}
+		return c.Send(fmt.Sprintf(`Matched rules: %s
+
+tags: %s
+archive: %t
+star: %t`,
+			strings.Join(result.Matched, ", "),
+			strings.Join(result.Tags, ", "),
+			result.Archive,
+			result.Star,
+		))
+	}
+}
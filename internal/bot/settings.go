@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/summarization"
+	tele "gopkg.in/telebot.v3"
+)
+
+const settingsText = "settings"
+
+const (
+	settingLanguage = "language"
+	settingLength   = "length"
+	settingFormat   = "format"
+)
+
+// settingsLanguages are offered as buttons, others are set with
+// "/settings language <name>".
+var settingsLanguages = []string{"English", "Russian", summarization.LanguageOriginal}
+
+var formatLabels = map[string]string{
+	summarization.FormatProse:   "prose",
+	summarization.FormatBullets: "bullets",
+	summarization.FormatTLDR:    "tl;dr",
+}
+
+func settingsHandler(settings *summarization.Settings) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		opts := settings.Get(c.Sender().ID)
+		if args := c.Args(); len(args) > 0 {
+			if len(args) < 2 || !updateSetting(&opts, args[0], strings.Join(args[1:], " ")) {
+				return c.Send("Usage: /settings [language|length|format] <value>")
+			}
+			if err := settings.Set(c.Sender().ID, opts); err != nil {
+				return c.Send(fmt.Sprintf("Failed to save settings: %s", describeError(err)))
+			}
+		}
+		return c.Send(formatSettings(opts), formSettingsButtons(opts))
+	}
+}
+
+func settingsCallbackHandler(settings *summarization.Settings) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		field, value, _ := strings.Cut(c.Callback().Data, "|")
+		opts := settings.Get(c.Sender().ID)
+		if !updateSetting(&opts, field, value) {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       "Error during changing settings: wrong callback data",
+			})
+		}
+		if err := settings.Set(c.Sender().ID, opts); err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during changing settings: %s", describeError(err)),
+			})
+		}
+		c.Edit(formatSettings(opts), formSettingsButtons(opts))
+		return c.Respond(&tele.CallbackResponse{
+			CallbackID: c.Callback().ID,
+			Text:       fmt.Sprintf("Summary %s is %s now", field, value),
+		})
+	}
+}
+
+// updateSetting reports false for unknown fields and values.
+func updateSetting(opts *summarization.Options, field string, value string) bool {
+	value = strings.TrimSpace(value)
+	switch field {
+	case settingLanguage:
+		if value == "" {
+			return false
+		}
+		opts.Language = value
+	case settingLength:
+		if !slices.Contains(summarization.Lengths, value) {
+			return false
+		}
+		opts.Length = value
+	case settingFormat:
+		if !slices.Contains(summarization.Formats, value) {
+			return false
+		}
+		opts.Format = value
+	default:
+		return false
+	}
+	return true
+}
+
+func formatSettings(opts summarization.Options) string {
+	return fmt.Sprintf(`⚙️ Summary settings
+
+%s
+
+Send /settings language <name> to pick another language.`, opts)
+}
+
+func formSettingsButtons(opts summarization.Options) *tele.ReplyMarkup {
+	selector := &tele.ReplyMarkup{}
+	var languages, lengths, formats tele.Row
+	for _, language := range settingsLanguages {
+		languages = append(languages, selector.Data(mark(language, strings.EqualFold(opts.Language, language)), settingsText, settingLanguage, language))
+	}
+	for _, length := range summarization.Lengths {
+		lengths = append(lengths, selector.Data(mark(length, opts.Length == length), settingsText, settingLength, length))
+	}
+	for _, format := range summarization.Formats {
+		formats = append(formats, selector.Data(mark(formatLabels[format], opts.Format == format), settingsText, settingFormat, format))
+	}
+	selector.Inline(languages, lengths, formats)
+	return selector
+}
+
+func mark(label string, selected bool) string {
+	if selected {
+		return "✓ " + label
+	}
+	return label
+}
@@ -0,0 +1,43 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/telegraph"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/usecase"
+	tele "gopkg.in/telebot.v3"
+)
+
+const instantViewText = "instant"
+
+func instantViewHandler(wallabotUseCase usecase.ArticleUseCase, publisher *telegraph.Publisher) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		entryID, err := strconv.ParseInt(c.Callback().Data, 10, 64)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during publishing entry: %s", describeError(err)),
+			})
+		}
+		article, err := wallabotUseCase.FindByID(requestContext(c), int(entryID))
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during publishing entry: %s", describeError(err)),
+			})
+		}
+		pageURL, err := publisher.Publish(requestContext(c), article.ID, article.Title, article.Url, article.Content)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{
+				CallbackID: c.Callback().ID,
+				Text:       fmt.Sprintf("Error during publishing entry: %s", describeError(err)),
+			})
+		}
+		// link preview is what makes Telegram show Instant View
+		c.Send(fmt.Sprintf("⚡ %s\n%s", article.Title, pageURL))
+		return c.Respond(&tele.CallbackResponse{
+			CallbackID: c.Callback().ID,
+		})
+	}
+}
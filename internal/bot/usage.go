@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/accounting"
+	tele "gopkg.in/telebot.v3"
+)
+
+const defaultUsageDays = 7
+
+// usageHandler serves /usage [days] with LLM cost per day and model.
+func usageHandler(ledger *accounting.Ledger) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		days := defaultUsageDays
+		if args := c.Args(); len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return c.Send("Usage: /usage [days], e.g. /usage 30")
+			}
+			days = n
+		}
+		entries := ledger.Since(time.Now().AddDate(0, 0, 1-days))
+		return c.Send(formatUsage(entries, days, ledger.MonthCost(), ledger.Budget()))
+	}
+}
+
+func formatUsage(entries []accounting.Entry, days int, monthCost float64, budget float64) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "💸 LLM usage for the last %d days\n", days)
+	if len(entries) == 0 {
+		sb.WriteString("\nNo requests")
+	}
+	day := ""
+	for _, entry := range entries {
+		if entry.Day != day {
+			day = entry.Day
+			fmt.Fprintf(&sb, "\n%s\n", day)
+		}
+		fmt.Fprintf(&sb, "%s: %d requests, %d+%d tokens, $%.4f\n",
+			entry.Model,
+			entry.Requests,
+			entry.PromptTokens,
+			entry.CompletionTokens,
+			entry.Cost,
+		)
+	}
+	fmt.Fprintf(&sb, "\nThis month: $%.2f", monthCost)
+	if budget > 0 {
+		fmt.Fprintf(&sb, " of $%.2f budget", budget)
+		if monthCost >= budget {
+			sb.WriteString(", LLM features are disabled")
+		}
+	}
+	return sb.String()
+}
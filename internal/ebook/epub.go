@@ -0,0 +1,223 @@
+package ebook
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Chapter is a single article inside a bundled e-book.
+type Chapter struct {
+	Title   string
+	URL     string
+	Content string
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+var packageTemplate = template.Must(template.New("opf").Funcs(template.FuncMap{"esc": html.EscapeString}).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="bookid">{{ .ID }}</dc:identifier>
+    <dc:title>{{ esc .Title }}</dc:title>
+    <dc:language>en</dc:language>
+    <dc:creator>wallabot</dc:creator>
+    <meta property="dcterms:modified">{{ .Modified }}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+{{- range $i, $c := .Chapters }}
+    <item id="chapter-{{ $i }}" href="chapter-{{ $i }}.xhtml" media-type="application/xhtml+xml"/>
+{{- end }}
+  </manifest>
+  <spine>
+{{- range $i, $c := .Chapters }}
+    <itemref idref="chapter-{{ $i }}"/>
+{{- end }}
+  </spine>
+</package>
+`))
+
+var navTemplate = template.Must(template.New("nav").Funcs(template.FuncMap{"esc": html.EscapeString}).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>{{ esc .Title }}</title></head>
+<body>
+  <nav epub:type="toc">
+    <h1>{{ esc .Title }}</h1>
+    <ol>
+{{- range $i, $c := .Chapters }}
+      <li><a href="chapter-{{ $i }}.xhtml">{{ esc $c.Title }}</a></li>
+{{- end }}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var chapterTemplate = template.Must(template.New("chapter").Funcs(template.FuncMap{"esc": html.EscapeString}).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>{{ esc .Title }}</title></head>
+<body>
+  <h1>{{ esc .Title }}</h1>
+{{- if .URL }}
+  <p><a href="{{ esc .URL }}">{{ esc .URL }}</a></p>
+{{- end }}
+{{ .Body }}
+</body>
+</html>
+`))
+
+// WriteEPUB writes an EPUB 3 book with one chapter per article.
+func WriteEPUB(w io.Writer, title string, chapters []Chapter) error {
+	zw := zip.NewWriter(w)
+
+	// mimetype must be the first entry and stored without compression
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	if err := writeFile(zw, "META-INF/container.xml", []byte(containerXML)); err != nil {
+		return err
+	}
+
+	book := struct {
+		ID       string
+		Title    string
+		Modified string
+		Chapters []Chapter
+	}{
+		ID:       bookID(chapters),
+		Title:    title,
+		Modified: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Chapters: chapters,
+	}
+	if err := writeTemplate(zw, "OEBPS/content.opf", packageTemplate, book); err != nil {
+		return err
+	}
+	if err := writeTemplate(zw, "OEBPS/nav.xhtml", navTemplate, book); err != nil {
+		return err
+	}
+	for i, chapter := range chapters {
+		page := struct {
+			Title string
+			URL   string
+			Body  string
+		}{
+			Title: chapter.Title,
+			URL:   chapter.URL,
+			Body:  toXHTML(chapter.Content),
+		}
+		if err := writeTemplate(zw, fmt.Sprintf("OEBPS/chapter-%d.xhtml", i), chapterTemplate, page); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeFile(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func writeTemplate(zw *zip.Writer, name string, tmpl *template.Template, data any) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	return writeFile(zw, name, buf.Bytes())
+}
+
+func bookID(chapters []Chapter) string {
+	h := sha1.New()
+	for _, c := range chapters {
+		io.WriteString(h, c.URL)
+	}
+	return fmt.Sprintf("urn:wallabot:%x", h.Sum(nil))
+}
+
+// droppedElements are not allowed or useless inside an e-book chapter.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Svg:      true,
+	atom.Noscript: true,
+	atom.Video:    true,
+	atom.Audio:    true,
+}
+
+// toXHTML re-serializes wallabag HTML so the chapter is well-formed XML:
+// void elements get closed and unsupported elements are dropped.
+func toXHTML(content string) string {
+	nodes, err := xhtml.ParseFragment(strings.NewReader(content), &xhtml.Node{
+		Type:     xhtml.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return "<p>" + html.EscapeString(content) + "</p>"
+	}
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		if n.Type == xhtml.CommentNode || (n.Type == xhtml.ElementNode && droppedElements[n.DataAtom]) {
+			continue
+		}
+		clean(n)
+		if err := xhtml.Render(&buf, n); err != nil {
+			return "<p>" + html.EscapeString(content) + "</p>"
+		}
+	}
+	return buf.String()
+}
+
+func clean(n *xhtml.Node) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		// namespaced and event attributes are not valid in plain XHTML
+		if a.Namespace == "" && !strings.Contains(a.Key, ":") && !strings.HasPrefix(a.Key, "on") {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == xhtml.CommentNode || (c.Type == xhtml.ElementNode && droppedElements[c.DataAtom]) {
+			n.RemoveChild(c)
+		} else {
+			clean(c)
+		}
+		c = next
+	}
+}
@@ -0,0 +1,250 @@
+// Package embeddings keeps vectors of library entries for semantic search.
+package embeddings
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+const (
+	vectorsBucket = "embeddings"
+	stateBucket   = "embeddings_state"
+	stateKey      = "sync"
+
+	// documentTokens of each entry are embedded, the beginning of an
+	// article describes it well enough for search
+	documentTokens = 2000
+)
+
+type Document struct {
+	ID      int
+	Title   string
+	Content string
+}
+
+type Match struct {
+	ID    int
+	Score float32
+}
+
+// record is stored per entry, vector is packed to keep the state file small.
+type record struct {
+	Model  string `json:"model"`
+	Vector []byte `json:"vector"`
+}
+
+type state struct {
+	Model string `json:"model"`
+	Since int64  `json:"since"`
+}
+
+// Index stores normalized vectors, so cosine similarity is a dot product.
+type Index struct {
+	embedder llm.Embedder
+	store    *storage.Store
+	text     extract.Options
+
+	mx      sync.RWMutex
+	loaded  bool
+	vectors map[int][]float32
+}
+
+// NewIndex creates index kept in store. Vectors are large and are written
+// on every sync, so the store should be separate from the bot state.
+func NewIndex(embedder llm.Embedder, store *storage.Store, text extract.Options) *Index {
+	return &Index{embedder: embedder, store: store, text: text}
+}
+
+// Since returns time of the last sync, zero when the index is empty or
+// was built with another model.
+func (ix *Index) Since() int64 {
+	var s state
+	if ok, err := ix.store.Get(stateBucket, stateKey, &s); !ok || err != nil || s.Model != ix.embedder.EmbeddingModel() {
+		return 0
+	}
+	return s.Since
+}
+
+func (ix *Index) SetSince(since int64) error {
+	return ix.store.Put(stateBucket, stateKey, state{Model: ix.embedder.EmbeddingModel(), Since: since})
+}
+
+// Add embeds documents and replaces their previous vectors.
+func (ix *Index) Add(ctx context.Context, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = ix.documentText(doc)
+	}
+	vectors, err := ix.embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if err := ix.load(); err != nil {
+		return err
+	}
+
+	model := ix.embedder.EmbeddingModel()
+	records := make(map[string]any, len(docs))
+	ix.mx.Lock()
+	for i, doc := range docs {
+		vector := normalize(vectors[i])
+		ix.vectors[doc.ID] = vector
+		records[strconv.Itoa(doc.ID)] = record{Model: model, Vector: pack(vector)}
+	}
+	ix.mx.Unlock()
+	return ix.store.PutMany(vectorsBucket, records)
+}
+
+func (ix *Index) Remove(id int) error {
+	ix.mx.Lock()
+	delete(ix.vectors, id)
+	ix.mx.Unlock()
+	return ix.store.Delete(vectorsBucket, strconv.Itoa(id))
+}
+
+func (ix *Index) Len() int {
+	if err := ix.load(); err != nil {
+		return 0
+	}
+	ix.mx.RLock()
+	defer ix.mx.RUnlock()
+	return len(ix.vectors)
+}
+
+// Search returns up to count entries closest to the query, best first.
+func (ix *Index) Search(ctx context.Context, query string, count int) ([]Match, error) {
+	if err := ix.load(); err != nil {
+		return nil, err
+	}
+	vectors, err := ix.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, errors.New("no embedding for the query")
+	}
+	return ix.nearest(normalize(vectors[0]), count), nil
+}
+
+// Similar returns up to count entries closest to the indexed entry, the
+// entry itself excluded. Entries not indexed yet have no neighbours.
+func (ix *Index) Similar(id int, count int) ([]Match, error) {
+	if err := ix.load(); err != nil {
+		return nil, err
+	}
+	ix.mx.RLock()
+	vector, ok := ix.vectors[id]
+	ix.mx.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	return ix.nearest(vector, count, id), nil
+}
+
+func (ix *Index) nearest(query []float32, count int, exclude ...int) []Match {
+	ix.mx.RLock()
+	matches := make([]Match, 0, len(ix.vectors))
+	for id, vector := range ix.vectors {
+		if len(vector) != len(query) || slices.Contains(exclude, id) {
+			continue
+		}
+		matches = append(matches, Match{ID: id, Score: dot(query, vector)})
+	}
+	ix.mx.RUnlock()
+	slices.SortFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return a.ID - b.ID
+	})
+	return matches[:min(count, len(matches))]
+}
+
+// load reads vectors of the current model from the store once.
+func (ix *Index) load() error {
+	ix.mx.Lock()
+	defer ix.mx.Unlock()
+	if ix.loaded {
+		return nil
+	}
+	model := ix.embedder.EmbeddingModel()
+	ix.vectors = map[int][]float32{}
+	for _, key := range ix.store.Keys(vectorsBucket) {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		var r record
+		if _, err := ix.store.Get(vectorsBucket, key, &r); err != nil {
+			return err
+		}
+		if r.Model == model {
+			ix.vectors[id] = unpack(r.Vector)
+		}
+	}
+	ix.loaded = true
+	return nil
+}
+
+func (ix *Index) documentText(doc Document) string {
+	text := doc.Title + "\n\n" + extract.Text(doc.Content, ix.text)
+	if chunks := llm.Split(text, documentTokens); len(chunks) > 0 {
+		return chunks[0]
+	}
+	return doc.Title
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func pack(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+func unpack(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
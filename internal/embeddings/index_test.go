@@ -0,0 +1,121 @@
+package embeddings
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+// fakeEmbedder maps texts to counts of a few keywords.
+type fakeEmbedder struct {
+	model string
+	calls int
+}
+
+var keywords = []string{"golang", "cooking", "chess"}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float32, len(keywords))
+		for j, keyword := range keywords {
+			vectors[i][j] = float32(strings.Count(strings.ToLower(text), keyword))
+		}
+	}
+	return vectors, nil
+}
+
+func (f *fakeEmbedder) EmbeddingModel() string {
+	return f.model
+}
+
+func TestIndexSearch(t *testing.T) {
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := NewIndex(&fakeEmbedder{model: "fake"}, store, extract.Options{})
+	err = ix.Add(context.Background(), []Document{
+		{ID: 1, Title: "Golang tips", Content: "<p>golang golang</p>"},
+		{ID: 2, Title: "Cooking", Content: "<p>cooking pasta with golang</p>"},
+		{ID: 3, Title: "Chess openings", Content: "<p>chess</p>"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+
+	matches, err := ix.Search(context.Background(), "how to write golang", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if len(matches) != 2 || matches[0].ID != 1 || matches[1].ID != 2 {
+		t.Errorf("Unexpected matches %v", matches)
+	}
+
+	if err := ix.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	// vectors survive reload from the store
+	reloaded := NewIndex(&fakeEmbedder{model: "fake"}, store, extract.Options{})
+	if reloaded.Len() != 2 {
+		t.Errorf("Unexpected index size %d", reloaded.Len())
+	}
+	matches, _ = reloaded.Search(context.Background(), "chess", 1)
+	if len(matches) != 1 || matches[0].ID != 3 {
+		t.Errorf("Unexpected matches after reload %v", matches)
+	}
+}
+
+func TestIndexModelChange(t *testing.T) {
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := NewIndex(&fakeEmbedder{model: "old"}, store, extract.Options{})
+	if err := ix.Add(context.Background(), []Document{{ID: 1, Title: "golang"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.SetSince(100); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := NewIndex(&fakeEmbedder{model: "new"}, store, extract.Options{})
+	if changed.Since() != 0 || changed.Len() != 0 {
+		t.Errorf("Vectors of another model should not be used")
+	}
+	if ix.Since() != 100 {
+		t.Errorf("Unexpected since %d", ix.Since())
+	}
+}
+
+func TestIndexSimilar(t *testing.T) {
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := NewIndex(&fakeEmbedder{model: "fake"}, store, extract.Options{})
+	err = ix.Add(context.Background(), []Document{
+		{ID: 1, Title: "Golang tips", Content: "<p>golang golang</p>"},
+		{ID: 2, Title: "Golang and chess", Content: "<p>chess engine</p>"},
+		{ID: 3, Title: "Cooking", Content: "<p>cooking</p>"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+
+	matches, err := ix.Similar(1, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if len(matches) != 2 || matches[0].ID != 2 || matches[1].ID != 3 {
+		t.Errorf("Unexpected matches %v", matches)
+	}
+	matches, _ = ix.Similar(42, 2)
+	if len(matches) != 0 {
+		t.Errorf("Entry missing in index should have no neighbours, got %v", matches)
+	}
+}
@@ -0,0 +1,114 @@
+// Package extract turns article HTML stored by wallabag into plain text
+// for language models, so their context is spent on words, not markup.
+package extract
+
+import (
+	"html"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/htmltext"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type Options struct {
+	// KeepCaptions preserves figure and image captions
+	KeepCaptions bool
+}
+
+// Text converts HTML to text: headings are prefixed with "#", list items
+// with "-", code blocks are fenced, scripts, navigation and forms are dropped.
+// Content which is not HTML is only unescaped and trimmed.
+func Text(content string, opts Options) string {
+	if !strings.Contains(content, "<") {
+		return htmltext.Cleanup(html.UnescapeString(content))
+	}
+	root, err := xhtml.Parse(strings.NewReader(content))
+	if err != nil {
+		return htmltext.Cleanup(content)
+	}
+	e := &extractor{opts: opts}
+	e.walk(root)
+	return htmltext.Cleanup(e.sb.String())
+}
+
+type extractor struct {
+	opts  Options
+	sb    htmltext.Builder
+	depth int
+}
+
+func (e *extractor) walk(n *xhtml.Node) {
+	switch n.Type {
+	case xhtml.TextNode:
+		e.sb.WriteText(n.Data, nil)
+		return
+	case xhtml.ElementNode:
+	default:
+		e.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Svg, atom.Head, atom.Form, atom.Button,
+		atom.Nav, atom.Aside, atom.Iframe, atom.Video, atom.Audio, atom.Template:
+		return
+	case atom.Figcaption:
+		if !e.opts.KeepCaptions {
+			return
+		}
+		e.sb.Block()
+		e.children(n)
+		e.sb.Block()
+	case atom.Img:
+		if alt := strings.TrimSpace(htmltext.Attr(n, "alt")); e.opts.KeepCaptions && alt != "" {
+			e.sb.Newline()
+			e.sb.WriteString("[image: " + alt + "]")
+			e.sb.Newline()
+		}
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		e.sb.Block()
+		e.sb.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		e.sb.WriteString(strings.TrimSpace(htmltext.CollapseSpaces(htmltext.TextContent(n))))
+		e.sb.Block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+		atom.Figure, atom.Table, atom.Blockquote, atom.Dl:
+		e.sb.Block()
+		e.children(n)
+		e.sb.Block()
+	case atom.Br:
+		e.sb.WriteString("\n")
+	case atom.Tr, atom.Dt, atom.Dd:
+		e.sb.Newline()
+		e.children(n)
+	case atom.Td, atom.Th:
+		e.children(n)
+		e.sb.WriteString(" | ")
+	case atom.Pre:
+		e.sb.Block()
+		e.sb.WriteString("```\n")
+		e.sb.WriteString(strings.Trim(htmltext.TextContent(n), "\n"))
+		e.sb.WriteString("\n```")
+		e.sb.Block()
+	case atom.Code, atom.Kbd, atom.Samp:
+		e.sb.WriteString("`" + htmltext.TextContent(n) + "`")
+	case atom.Ul, atom.Ol:
+		e.sb.Newline()
+		e.depth++
+		e.children(n)
+		e.depth--
+		e.sb.Newline()
+	case atom.Li:
+		e.sb.Newline()
+		e.sb.WriteString(strings.Repeat("  ", max(e.depth-1, 0)) + "- ")
+		e.children(n)
+	default:
+		e.children(n)
+	}
+}
+
+func (e *extractor) children(n *xhtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		e.walk(c)
+	}
+}
@@ -0,0 +1,54 @@
+package extract
+
+import "testing"
+
+func TestText(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		opts    Options
+		text    string
+	}{
+		{
+			name:    "paragraphs and headings",
+			content: `<h2>Intro <em>here</em></h2><p>First   <b>bold</b> line.</p><p>Second</p>`,
+			text:    "## Intro here\n\nFirst bold line.\n\nSecond",
+		},
+		{
+			name:    "noise",
+			content: `<nav><a href="/">Home</a></nav><script>alert(1)</script><style>p{}</style><p>Text</p><form><button>Go</button></form>`,
+			text:    "Text",
+		},
+		{
+			name:    "code",
+			content: "<p>Run <code>go test</code>:</p><pre><code>func main() {\n\tfmt.Println(1)\n}</code></pre>",
+			text:    "Run `go test`:\n\n```\nfunc main() {\n\tfmt.Println(1)\n}\n```",
+		},
+		{
+			name:    "lists",
+			content: `<ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul>`,
+			text:    "- One\n- Two\n  - Nested",
+		},
+		{
+			name:    "captions dropped",
+			content: `<figure><img src="a.png" alt="Chart"><figcaption>Figure 1</figcaption></figure><p>Body</p>`,
+			text:    "Body",
+		},
+		{
+			name:    "captions kept",
+			content: `<figure><img src="a.png" alt="Chart"><figcaption>Figure 1</figcaption></figure><p>Body</p>`,
+			opts:    Options{KeepCaptions: true},
+			text:    "[image: Chart]\n\nFigure 1\n\nBody",
+		},
+		{
+			name:    "plain text",
+			content: "  Tom &amp; Jerry \n",
+			text:    "Tom & Jerry",
+		},
+	}
+	for _, c := range cases {
+		if text := Text(c.content, c.opts); text != c.text {
+			t.Errorf("%s: unexpected text %q", c.name, text)
+		}
+	}
+}
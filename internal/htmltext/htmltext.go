@@ -0,0 +1,86 @@
+// Package htmltext holds helpers shared by walkers which flatten article
+// HTML into text: plain text for language models and Telegram messages.
+package htmltext
+
+import (
+	"regexp"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	spaces        = regexp.MustCompile(`\s+`)
+	manyNewlines  = regexp.MustCompile(`\n{3,}`)
+	trailingSpace = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// Builder collects flattened text and keeps line and paragraph breaks
+// from piling up.
+type Builder struct {
+	strings.Builder
+}
+
+// WriteText writes a text node with collapsed whitespace, dropping leading
+// spaces at the start of a line. Escape, when set, is applied afterwards.
+func (b *Builder) WriteText(s string, escape func(string) string) {
+	s = CollapseSpaces(s)
+	if b.Len() == 0 || strings.HasSuffix(b.String(), "\n") {
+		s = strings.TrimLeft(s, " ")
+	}
+	if escape != nil {
+		s = escape(s)
+	}
+	b.WriteString(s)
+}
+
+// Newline ends the current line unless it is empty.
+func (b *Builder) Newline() {
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+}
+
+// Block separates paragraphs with an empty line.
+func (b *Builder) Block() {
+	b.Newline()
+	b.WriteString("\n")
+}
+
+func CollapseSpaces(s string) string {
+	return spaces.ReplaceAllString(s, " ")
+}
+
+// TextContent returns text of the node and its children, <br> becomes
+// a line break.
+func TextContent(n *xhtml.Node) string {
+	if n.Type == xhtml.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Br {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(TextContent(c))
+	}
+	return sb.String()
+}
+
+func Attr(n *xhtml.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// Cleanup drops trailing spaces of lines and extra empty lines.
+func Cleanup(s string) string {
+	s = trailingSpace.ReplaceAllString(s, "\n")
+	s = manyNewlines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultOpenAIEmbeddingModel     = "text-embedding-3-small"
+	defaultOpenRouterEmbeddingModel = "openai/text-embedding-3-small"
+	defaultLocalEmbeddingModel      = "nomic-embed-text"
+)
+
+// Embedder turns texts into vectors for semantic search.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// EmbeddingModel identifies vectors, ones from different models
+	// can't be compared.
+	EmbeddingModel() string
+}
+
+var defaultEmbeddingModels = map[string]string{
+	ProviderOpenAI:     defaultOpenAIEmbeddingModel,
+	ProviderOpenRouter: defaultOpenRouterEmbeddingModel,
+	ProviderLocal:      defaultLocalEmbeddingModel,
+}
+
+// NewEmbedder builds embedder of a registered provider, Options.EmbeddingModel
+// selects the model.
+func NewEmbedder(name string, opts Options) (Embedder, error) {
+	if opts.EmbeddingModel == "" {
+		opts.EmbeddingModel = defaultEmbeddingModels[name]
+	}
+	cl, err := New(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := cl.(Embedder)
+	if !ok {
+		return nil, fmt.Errorf("llm provider %s doesn't support embeddings", name)
+	}
+	return embedder, nil
+}
+
+func (p openaiCompatible) EmbeddingModel() string {
+	return p.embeddingModel
+}
+
+func (p openaiCompatible) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if p.embeddingModel == "" {
+		return nil, errors.New("embedding model was not configured")
+	}
+	resp, err := p.cl.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: openai.EmbeddingModel(p.embeddingModel),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(resp.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, e := range resp.Data {
+		if e.Index < 0 || e.Index >= len(vectors) {
+			return nil, fmt.Errorf("unexpected embedding index %d", e.Index)
+		}
+		vectors[e.Index] = e.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,218 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrUnavailable is returned when no provider in the chain could serve a request.
+var ErrUnavailable = errors.New("llm providers are unavailable")
+
+// Provider is a named link of the fallback chain.
+type Provider struct {
+	Name string
+	ChatCompleter
+}
+
+type RetryPolicy struct {
+	// Attempts per provider, including the first one
+	Attempts       int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// BreakerPolicy skips a provider for Cooldown after Threshold failed requests in a row.
+type BreakerPolicy struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{Attempts: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+}
+
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{Threshold: 3, Cooldown: 5 * time.Minute}
+}
+
+type link struct {
+	Provider
+
+	mx        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// Fallback tries providers in order, retrying transient errors with
+// exponential backoff and skipping providers that keep failing. Providers
+// refusing requests with auth or not found errors are passed over, invalid
+// requests are returned as is.
+type Fallback struct {
+	links   []*link
+	retry   RetryPolicy
+	breaker BreakerPolicy
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewFallback(providers []Provider, retry RetryPolicy, breaker BreakerPolicy) *Fallback {
+	links := make([]*link, len(providers))
+	for i, p := range providers {
+		links[i] = &link{Provider: p}
+	}
+	if retry.Attempts < 1 {
+		retry.Attempts = 1
+	}
+	return &Fallback{
+		links:   links,
+		retry:   retry,
+		breaker: breaker,
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+}
+
+func (f *Fallback) Complete(ctx context.Context, req Request) (Response, error) {
+	var errs []error
+	for _, l := range f.links {
+		if !l.available(f.now()) {
+			log.Printf("llm provider %s is skipped after repeated failures", l.Name)
+			continue
+		}
+		resp, err := f.completeWithRetries(ctx, l, req)
+		if err == nil {
+			l.succeeded()
+			if resp.Provider == "" {
+				resp.Provider = l.Name
+			}
+			log.Printf("llm request served by %s (%s)", l.Name, resp.Model)
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return Response{}, ctx.Err()
+		}
+		if misconfigured(err) {
+			// the provider can't serve anything, e.g. key was revoked or
+			// model removed, next ones might
+			log.Printf("llm provider %s refused request: %v", l.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", l.Name, err))
+			continue
+		}
+		if !Retryable(err) {
+			// the request itself was rejected, it says nothing about
+			// health of the provider
+			log.Printf("llm provider %s rejected request: %v", l.Name, err)
+			return Response{}, fmt.Errorf("%s: %w", l.Name, err)
+		}
+		log.Printf("llm provider %s failed: %v", l.Name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", l.Name, err))
+		if l.failed(f.now(), f.breaker) {
+			log.Printf("llm provider %s is disabled for %s", l.Name, f.breaker.Cooldown)
+		}
+	}
+	return Response{}, errors.Join(append([]error{ErrUnavailable}, errs...)...)
+}
+
+// ContextWindow is the smallest window in the chain, so requests fit
+// whichever provider serves them.
+func (f *Fallback) ContextWindow() int {
+	window := 0
+	for _, l := range f.links {
+		if w := WindowOf(l.ChatCompleter); window == 0 || w < window {
+			window = w
+		}
+	}
+	return window
+}
+
+func (f *Fallback) completeWithRetries(ctx context.Context, l *link, req Request) (Response, error) {
+	backoff := f.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := l.Complete(ctx, req)
+		if err == nil || attempt >= f.retry.Attempts || !Retryable(err) {
+			return resp, err
+		}
+		log.Printf("llm provider %s attempt %d failed, retrying in %s: %v", l.Name, attempt, backoff, err)
+		if err := f.sleep(ctx, backoff); err != nil {
+			return Response{}, err
+		}
+		backoff = min(backoff*2, f.retry.MaxBackoff)
+	}
+}
+
+func (l *link) available(now time.Time) bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	return !now.Before(l.openUntil)
+}
+
+func (l *link) succeeded() {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.failures = 0
+	l.openUntil = time.Time{}
+}
+
+// failed records a failure and reports whether the breaker has opened.
+func (l *link) failed(now time.Time, breaker BreakerPolicy) bool {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	l.failures++
+	if breaker.Threshold > 0 && l.failures >= breaker.Threshold {
+		l.openUntil = now.Add(breaker.Cooldown)
+		return true
+	}
+	return false
+}
+
+// Retryable reports whether the error is transient: rate limits, server
+// errors, timeouts and failed connections.
+func Retryable(err error) bool {
+	if code, ok := statusCode(err); ok {
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// misconfigured reports whether the provider refused the request because
+// of its own setup: invalid key, missing permissions or unknown model.
+func misconfigured(err error) bool {
+	code, ok := statusCode(err)
+	return ok && (code == http.StatusUnauthorized || code == http.StatusForbidden || code == http.StatusNotFound)
+}
+
+func statusCode(err error) (int, bool) {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode, true
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type scriptedCompleter struct {
+	errs  []error
+	calls int
+}
+
+func (s *scriptedCompleter) Complete(ctx context.Context, req Request) (Response, error) {
+	s.calls++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return Response{}, err
+		}
+	}
+	return Response{Content: "ok", Model: "test"}, nil
+}
+
+func newTestFallback(providers []Provider, breaker BreakerPolicy) (*Fallback, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFallback(providers, RetryPolicy{Attempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Second}, breaker)
+	f.now = func() time.Time { return now }
+	f.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	return f, &now
+}
+
+func TestFallbackRetriesTransientErrors(t *testing.T) {
+	primary := &scriptedCompleter{errs: []error{
+		&openai.APIError{HTTPStatusCode: http.StatusTooManyRequests},
+		&openai.RequestError{HTTPStatusCode: http.StatusBadGateway},
+	}}
+	f, _ := newTestFallback([]Provider{{Name: "primary", ChatCompleter: primary}}, DefaultBreakerPolicy())
+
+	resp, err := f.Complete(context.Background(), Request{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if primary.calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", primary.calls)
+	}
+	if resp.Provider != "primary" {
+		t.Errorf("Unexpected provider %s", resp.Provider)
+	}
+}
+
+func TestFallbackSwitchesProvider(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	primary := &scriptedCompleter{errs: []error{refused, refused, refused}}
+	secondary := &scriptedCompleter{}
+	f, _ := newTestFallback([]Provider{
+		{Name: "primary", ChatCompleter: primary},
+		{Name: "secondary", ChatCompleter: secondary},
+	}, DefaultBreakerPolicy())
+
+	resp, err := f.Complete(context.Background(), Request{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if primary.calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", primary.calls)
+	}
+	if resp.Provider != "secondary" {
+		t.Errorf("Unexpected provider %s", resp.Provider)
+	}
+}
+
+func TestFallbackReturnsPermanentErrors(t *testing.T) {
+	invalid := &openai.APIError{HTTPStatusCode: http.StatusBadRequest}
+	primary := &scriptedCompleter{errs: []error{invalid, invalid, invalid}}
+	secondary := &scriptedCompleter{}
+	f, _ := newTestFallback([]Provider{
+		{Name: "primary", ChatCompleter: primary},
+		{Name: "secondary", ChatCompleter: secondary},
+	}, BreakerPolicy{Threshold: 2, Cooldown: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		_, err := f.Complete(context.Background(), Request{})
+		if !errors.Is(err, invalid) || errors.Is(err, ErrUnavailable) {
+			t.Fatalf("Expected request error, got %v", err)
+		}
+	}
+	if primary.calls != 3 || secondary.calls != 0 {
+		t.Errorf("Permanent errors should not be retried, got %d and %d calls", primary.calls, secondary.calls)
+	}
+	// the breaker stays closed
+	if resp, err := f.Complete(context.Background(), Request{}); err != nil || resp.Provider != "primary" {
+		t.Errorf("Unexpected response %v, %v", resp, err)
+	}
+}
+
+func TestFallbackSkipsMisconfiguredProvider(t *testing.T) {
+	for _, code := range []int{http.StatusUnauthorized, http.StatusNotFound} {
+		refused := &openai.APIError{HTTPStatusCode: code}
+		primary := &scriptedCompleter{errs: []error{refused}}
+		secondary := &scriptedCompleter{}
+		f, _ := newTestFallback([]Provider{
+			{Name: "primary", ChatCompleter: primary},
+			{Name: "secondary", ChatCompleter: secondary},
+		}, DefaultBreakerPolicy())
+
+		resp, err := f.Complete(context.Background(), Request{})
+		if err != nil {
+			t.Fatalf("Unexpected error on %d: %v", code, err)
+		}
+		if primary.calls != 1 {
+			t.Errorf("Expected no retries on %d, got %d calls", code, primary.calls)
+		}
+		if resp.Provider != "secondary" {
+			t.Errorf("Unexpected provider %s on %d", resp.Provider, code)
+		}
+	}
+}
+
+func TestFallbackCircuitBreaker(t *testing.T) {
+	failing := &scriptedCompleter{}
+	for i := 0; i < 6; i++ {
+		failing.errs = append(failing.errs, &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable})
+	}
+	f, now := newTestFallback(
+		[]Provider{{Name: "failing", ChatCompleter: failing}},
+		BreakerPolicy{Threshold: 2, Cooldown: time.Minute},
+	)
+
+	for i := 0; i < 2; i++ {
+		if _, err := f.Complete(context.Background(), Request{}); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("Expected ErrUnavailable, got %v", err)
+		}
+	}
+	if _, err := f.Complete(context.Background(), Request{}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("Expected ErrUnavailable, got %v", err)
+	}
+	if failing.calls != 6 {
+		t.Errorf("Open breaker should skip provider, got %d calls", failing.calls)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	failing.errs = nil
+	if _, err := f.Complete(context.Background(), Request{}); err != nil {
+		t.Errorf("Provider should be tried again after cooldown, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+type Message struct {
+	Role    string
+	Content string
+}
+
+type Request struct {
+	Messages []Message
+	// Schema asks for JSON output matching it, providers without structured
+	// output support ignore it, so answers still have to be validated.
+	Schema *Schema
+}
+
+// Schema is a JSON schema of expected answer, its root must be an object.
+type Schema struct {
+	Name       string
+	Definition json.RawMessage
+}
+
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+type Response struct {
+	Content string
+	// Model and Provider which actually served the request
+	Model    string
+	Provider string
+	Usage    Usage
+}
+
+// ChatCompleter is implemented by every LLM provider, features like tagging
+// and summarization are built on top of it.
+type ChatCompleter interface {
+	Complete(ctx context.Context, req Request) (Response, error)
+}
+
+// Options are shared by all providers, zero values mean provider defaults.
+type Options struct {
+	APIKey      string
+	BaseURL     string
+	Model       string
+	Temperature float32
+	MaxTokens   int
+	Timeout     time.Duration
+	Proxy       *url.URL
+	// ContextWindow in tokens overrides the known limit of the model
+	ContextWindow int
+	// EmbeddingModel is used by Embedder, see NewEmbedder
+	EmbeddingModel string
+}
+
+var ErrNoChoices = errors.New("model returned no choices")
+
+// Truncate cuts s to at most limit bytes without splitting a rune.
+func Truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	ProviderOpenAI     = "openai"
+	ProviderOpenRouter = "openrouter"
+	ProviderLocal      = "local"
+
+	openrouterBaseURL = "https://openrouter.ai/api/v1"
+
+	defaultOpenAIModel     = openai.GPT4
+	defaultOpenRouterModel = "anthropic/claude-3.5-sonnet"
+	defaultLocalModel      = "llama3.1"
+
+	defaultTimeout = 2 * time.Minute
+	// local models on modest hardware are much slower than hosted ones
+	defaultLocalTimeout = 5 * time.Minute
+)
+
+// openaiCompatible serves every provider speaking OpenAI chat completions API.
+type openaiCompatible struct {
+	cl          *openai.Client
+	name        string
+	model       string
+	temperature float32
+	maxTokens   int
+	// structured tells whether endpoint understands json_schema response format
+	structured     bool
+	contextWindow  int
+	embeddingModel string
+}
+
+func NewOpenAI(opts Options) (ChatCompleter, error) {
+	if opts.APIKey == "" {
+		return nil, errors.New("key was not provided for openai")
+	}
+	p := newOpenaiCompatible(ProviderOpenAI, opts, defaultOpenAIModel, defaultTimeout)
+	p.structured = supportsJSONSchema(p.model)
+	return p, nil
+}
+
+func NewOpenRouter(opts Options) (ChatCompleter, error) {
+	if strings.TrimSpace(opts.APIKey) == "" {
+		return nil, errors.New("key was not provided for openrouter")
+	}
+	opts.APIKey = strings.TrimSpace(opts.APIKey)
+	if opts.BaseURL == "" {
+		opts.BaseURL = openrouterBaseURL
+	}
+	p := newOpenaiCompatible(ProviderOpenRouter, opts, defaultOpenRouterModel, defaultTimeout)
+	p.structured = true
+	return p, nil
+}
+
+// NewLocal talks to a self-hosted OpenAI-compatible endpoint
+// (Ollama, llama.cpp server), so article content never leaves the network.
+func NewLocal(opts Options) (ChatCompleter, error) {
+	if opts.BaseURL == "" {
+		return nil, errors.New("base url was not provided for local llm")
+	}
+	return newOpenaiCompatible(ProviderLocal, opts, defaultLocalModel, defaultLocalTimeout), nil
+}
+
+// jsonSchemaModels are OpenAI model prefixes accepting json_schema response
+// format, older ones like gpt-4 reject such requests.
+var jsonSchemaModels = []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4"}
+
+func supportsJSONSchema(model string) bool {
+	model = strings.ToLower(model)
+	for _, prefix := range jsonSchemaModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newOpenaiCompatible(name string, opts Options, defaultModel string, defaultTimeout time.Duration) openaiCompatible {
+	config := openai.DefaultConfig(opts.APIKey)
+	if opts.BaseURL != "" {
+		config.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	httpClient := &http.Client{Timeout: timeout}
+	if opts.Proxy != nil {
+		httpClient.Transport = &http.Transport{
+			Proxy: http.ProxyURL(opts.Proxy),
+		}
+	}
+	config.HTTPClient = httpClient
+
+	model := opts.Model
+	if model == "" {
+		model = defaultModel
+	}
+	contextWindow := opts.ContextWindow
+	if contextWindow == 0 {
+		contextWindow = ContextWindow(model)
+	}
+	return openaiCompatible{
+		cl:             openai.NewClientWithConfig(config),
+		name:           name,
+		model:          model,
+		temperature:    opts.Temperature,
+		maxTokens:      opts.MaxTokens,
+		contextWindow:  contextWindow,
+		embeddingModel: opts.EmbeddingModel,
+	}
+}
+
+func (p openaiCompatible) ContextWindow() int {
+	return p.contextWindow
+}
+
+func (p openaiCompatible) Complete(ctx context.Context, req Request) (Response, error) {
+	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = openai.ChatCompletionMessage{Role: m.Role, Content: m.Content}
+	}
+	request := openai.ChatCompletionRequest{
+		Model:       p.model,
+		Messages:    messages,
+		Temperature: p.temperature,
+		MaxTokens:   p.maxTokens,
+	}
+	if req.Schema != nil && p.structured {
+		request.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   req.Schema.Name,
+				Schema: req.Schema.Definition,
+				Strict: true,
+			},
+		}
+	}
+	resp, err := p.cl.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return Response{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return Response{}, ErrNoChoices
+	}
+	model := resp.Model
+	if model == "" {
+		model = p.model
+	}
+	return Response{
+		Content:  resp.Choices[0].Message.Content,
+		Model:    model,
+		Provider: p.name,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}, nil
+}
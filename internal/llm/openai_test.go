@@ -0,0 +1,216 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestLocalProviderComplete(t *testing.T) {
+	model := "qwen2.5"
+
+	// Start a local fake of OpenAI-compatible API
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Incorrect path %s", req.URL.Path)
+			http.NotFound(rw, req)
+			return
+		}
+		var request openai.ChatCompletionRequest
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Model != model {
+			t.Errorf("Unexpected model %s", request.Model)
+		}
+		if request.MaxTokens != 100 {
+			t.Errorf("Unexpected max tokens %d", request.MaxTokens)
+		}
+		if len(request.Messages) != 2 || request.Messages[0].Role != RoleSystem {
+			t.Errorf("Unexpected messages %v", request.Messages)
+		}
+		response, _ := json.Marshal(openai.ChatCompletionResponse{
+			Model: model,
+			Choices: []openai.ChatCompletionChoice{
+				{Message: openai.ChatCompletionMessage{
+					Role:    openai.ChatMessageRoleAssistant,
+					Content: "answer",
+				}},
+			},
+			Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 2},
+		})
+		rw.Write(response)
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	cl, err := New(ProviderLocal, Options{BaseURL: server.URL + "/v1/", Model: model, MaxTokens: 100})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	resp, err := cl.Complete(context.Background(), Request{Messages: []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: "question"},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if resp.Content != "answer" || resp.Provider != ProviderLocal || resp.Model != model {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if resp.Usage.PromptTokens != 10 || resp.Usage.CompletionTokens != 2 {
+		t.Errorf("Unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestNewValidatesProviders(t *testing.T) {
+	if _, err := New("unknown", Options{}); err == nil {
+		t.Errorf("Expected error for unknown provider")
+	}
+	if _, err := New(ProviderOpenAI, Options{}); err == nil {
+		t.Errorf("Expected error for openai without key")
+	}
+	if _, err := New(ProviderLocal, Options{}); err == nil {
+		t.Errorf("Expected error for local without base url")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("привет", 3); got != "п" {
+		t.Errorf("Truncate split a rune: %q", got)
+	}
+	if got := Truncate("short", 10); got != "short" {
+		t.Errorf("Truncate changed short string: %q", got)
+	}
+}
+
+func TestOpenAIProviderRequestsSchema(t *testing.T) {
+	// Start a local fake of OpenAI API
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var request struct {
+			ResponseFormat struct {
+				Type       string `json:"type"`
+				JSONSchema struct {
+					Name   string          `json:"name"`
+					Schema json.RawMessage `json:"schema"`
+				} `json:"json_schema"`
+			} `json:"response_format"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.ResponseFormat.Type != "json_schema" || request.ResponseFormat.JSONSchema.Name != "tags" {
+			t.Errorf("Unexpected response format %+v", request.ResponseFormat)
+		}
+		if string(request.ResponseFormat.JSONSchema.Schema) != `{"type":"object"}` {
+			t.Errorf("Unexpected schema %s", request.ResponseFormat.JSONSchema.Schema)
+		}
+		response, _ := json.Marshal(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{
+				{Message: openai.ChatCompletionMessage{Content: `{}`}},
+			},
+		})
+		rw.Write(response)
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	cl, err := New(ProviderOpenAI, Options{APIKey: "key", BaseURL: server.URL, Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	_, err = cl.Complete(context.Background(), Request{
+		Messages: []Message{{Role: RoleUser, Content: "question"}},
+		Schema:   &Schema{Name: "tags", Definition: json.RawMessage(`{"type":"object"}`)},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+}
+
+func TestOpenAIDefaultModelSkipsSchema(t *testing.T) {
+	// Start a local fake of OpenAI API
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var request map[string]json.RawMessage
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if string(request["model"]) != `"`+defaultOpenAIModel+`"` {
+			t.Errorf("Unexpected model %s", request["model"])
+		}
+		// gpt-4 answers 400 to json_schema response format
+		if format, ok := request["response_format"]; ok {
+			http.Error(rw, `{"error":{"message":"Invalid parameter: response_format"}}`, http.StatusBadRequest)
+			t.Errorf("Unexpected response format %s", format)
+			return
+		}
+		response, _ := json.Marshal(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{
+				{Message: openai.ChatCompletionMessage{Content: `{"tags":[]}`}},
+			},
+		})
+		rw.Write(response)
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	cl, err := New(ProviderOpenAI, Options{APIKey: "key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	_, err = cl.Complete(context.Background(), Request{
+		Messages: []Message{{Role: RoleUser, Content: "question"}},
+		Schema:   &Schema{Name: "tags", Definition: json.RawMessage(`{"type":"object"}`)},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+}
+
+func TestLocalProviderEmbed(t *testing.T) {
+	// Start a local fake of OpenAI-compatible API
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/embeddings" {
+			t.Errorf("Incorrect path %s", req.URL.Path)
+			http.NotFound(rw, req)
+			return
+		}
+		var request openai.EmbeddingRequest
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Model != defaultLocalEmbeddingModel {
+			t.Errorf("Unexpected model %s", request.Model)
+		}
+		// answer in reverse order to check ordering by index
+		response, _ := json.Marshal(openai.EmbeddingResponse{
+			Data: []openai.Embedding{
+				{Index: 1, Embedding: []float32{0, 1}},
+				{Index: 0, Embedding: []float32{1, 0}},
+			},
+		})
+		rw.Write(response)
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	embedder, err := NewEmbedder(ProviderLocal, Options{BaseURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	vectors, err := embedder.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("Unexpected vectors %v", vectors)
+	}
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory builds a provider from shared options.
+type Factory func(opts Options) (ChatCompleter, error)
+
+var (
+	registryMx sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes provider available by name, registering the same
+// name twice replaces the previous factory.
+func Register(name string, factory Factory) {
+	registryMx.Lock()
+	defer registryMx.Unlock()
+	registry[name] = factory
+}
+
+// New builds a registered provider.
+func New(name string, opts Options) (ChatCompleter, error) {
+	registryMx.RLock()
+	factory, ok := registry[name]
+	registryMx.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown llm provider %s, available: %v", name, Providers())
+	}
+	return factory(opts)
+}
+
+// Providers lists registered provider names.
+func Providers() []string {
+	registryMx.RLock()
+	defer registryMx.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(ProviderOpenAI, NewOpenAI)
+	Register(ProviderOpenRouter, NewOpenRouter)
+	Register(ProviderLocal, NewLocal)
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultContextWindow is assumed for models missing in contextWindows.
+const DefaultContextWindow = 8192
+
+// contextWindows in tokens by model name prefix, longer prefixes win.
+var contextWindows = map[string]int{
+	"gpt-4o":                  128000,
+	"gpt-4-turbo":             128000,
+	"gpt-4-1106":              128000,
+	"gpt-4-0125":              128000,
+	"gpt-4-32k":               32768,
+	"gpt-4":                   8192,
+	"gpt-3.5-turbo":           16385,
+	"openai/gpt-4o":           128000,
+	"anthropic/claude":        200000,
+	"google/gemini":           1000000,
+	"meta-llama/llama-3.1":    128000,
+	"mistralai/mistral-large": 128000,
+	"llama3.1":                128000,
+	"llama3.2":                128000,
+	"llama3":                  8192,
+	"qwen2.5":                 32768,
+	"mistral":                 32768,
+}
+
+// ContextWindower is implemented by completers knowing how many tokens
+// a single request may hold.
+type ContextWindower interface {
+	ContextWindow() int
+}
+
+// ContextWindow returns limit of the model, see contextWindows.
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	window, matched := DefaultContextWindow, ""
+	for prefix, size := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			window, matched = size, prefix
+		}
+	}
+	return window
+}
+
+// WindowOf returns context window of the completer or the default one.
+func WindowOf(cl ChatCompleter) int {
+	if w, ok := cl.(ContextWindower); ok && w.ContextWindow() > 0 {
+		return w.ContextWindow()
+	}
+	return DefaultContextWindow
+}
+
+// EstimateTokens approximates token count without a tokenizer: about four
+// latin characters per token, while other scripts take one token per
+// two characters or less.
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + (other+1)/2
+}
+
+// Split cuts text into chunks of at most maxTokens estimated tokens,
+// preferring paragraph, line and sentence boundaries.
+func Split(text string, maxTokens int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if maxTokens <= 0 || EstimateTokens(text) <= maxTokens {
+		return []string{text}
+	}
+	for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+		if parts := strings.SplitAfter(text, sep); len(parts) > 1 {
+			return pack(parts, maxTokens)
+		}
+	}
+	return splitRunes(text, maxTokens)
+}
+
+// pack joins consecutive parts while they fit, splitting oversized ones further.
+func pack(parts []string, maxTokens int) []string {
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+	for _, part := range parts {
+		if EstimateTokens(part) > maxTokens {
+			flush()
+			chunks = append(chunks, Split(part, maxTokens)...)
+			continue
+		}
+		if EstimateTokens(current.String()+part) > maxTokens {
+			flush()
+		}
+		current.WriteString(part)
+	}
+	flush()
+	return chunks
+}
+
+// splitRunes is the last resort for text without any separators.
+func splitRunes(text string, maxTokens int) []string {
+	var chunks []string
+	for text != "" {
+		cut := len(text)
+		for EstimateTokens(text[:cut]) > maxTokens {
+			cut = len(Truncate(text, cut/2+cut/4))
+		}
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(text)
+		}
+		chunks = append(chunks, text[:cut])
+		text = text[cut:]
+	}
+	return chunks
+}
@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestContextWindow(t *testing.T) {
+	cases := map[string]int{
+		"gpt-4o-mini":                 128000,
+		"gpt-4":                       8192,
+		"anthropic/claude-3.5-sonnet": 200000,
+		"llama3.1:8b":                 128000,
+		"llama3":                      8192,
+		"unknown":                     DefaultContextWindow,
+	}
+	for model, expected := range cases {
+		if window := ContextWindow(model); window != expected {
+			t.Errorf("ContextWindow(%s) = %d, want %d", model, window, expected)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	texts := []string{
+		strings.Repeat("First paragraph sentence. ", 50) + "\n\n" + strings.Repeat("Second one. ", 50),
+		strings.Repeat("Длинная строка без разделителей", 40),
+		strings.Repeat("word ", 1000),
+	}
+	for _, text := range texts {
+		chunks := Split(text, 100)
+		if len(chunks) < 2 {
+			t.Errorf("Text was not split: %d chunks", len(chunks))
+		}
+		total := 0
+		for _, chunk := range chunks {
+			if tokens := EstimateTokens(chunk); tokens > 100 {
+				t.Errorf("Chunk has %d tokens", tokens)
+			}
+			if !utf8.ValidString(chunk) {
+				t.Errorf("Chunk is not valid UTF-8: %q", chunk)
+			}
+			total += len(strings.Join(strings.Fields(chunk), ""))
+		}
+		if expected := len(strings.Join(strings.Fields(text), "")); total != expected {
+			t.Errorf("Content lost during split: %d != %d", total, expected)
+		}
+	}
+	if chunks := Split("short", 100); len(chunks) != 1 || chunks[0] != "short" {
+		t.Errorf("Unexpected chunks %v", chunks)
+	}
+}
@@ -0,0 +1,133 @@
+// Package qa answers questions about a saved article, keeping a short
+// conversation per user and entry.
+package qa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+const (
+	historyBucket = "qa_history"
+	// maxHistory messages are kept, three questions with answers
+	maxHistory = 6
+	// maxConversations are kept across all users and entries, older ones
+	// are dropped
+	maxConversations = 100
+	// reservedTokens are left for history, question and the answer
+	reservedTokens = 2048
+)
+
+const qaPrompt = `
+You answer questions about the article below. Use only the article content, if the article doesn't cover the question say so instead of guessing. Quote the article when it helps. Answer concisely in the language of the question, as plain text without markdown.
+`
+
+type Answerer struct {
+	cl    llm.ChatCompleter
+	store *storage.Store
+	text  extract.Options
+	now   func() time.Time
+}
+
+// conversation is stored per user and entry.
+type conversation struct {
+	Messages  []llm.Message `json:"messages"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// NewAnswerer creates answerer on top of completer, nil completer gives
+// an answerer which always fails, as there is no provider configured.
+func NewAnswerer(cl llm.ChatCompleter, store *storage.Store, text extract.Options) *Answerer {
+	return &Answerer{cl: cl, store: store, text: text, now: time.Now}
+}
+
+// Ask answers the question about the entry, previous questions of the
+// user about the same entry are sent along.
+func (a *Answerer) Ask(ctx context.Context, userID int64, entryID int, title, content, question string) (string, error) {
+	if a.cl == nil {
+		return "", errors.New("llm provider was not configured for questions")
+	}
+	content = extract.Text(content, a.text)
+	if content == "" {
+		return "", errors.New("entry has no content to ask about")
+	}
+	budget := max(llm.WindowOf(a.cl)-llm.EstimateTokens(qaPrompt)-reservedTokens, reservedTokens)
+	if chunks := llm.Split(content, budget); len(chunks) > 0 {
+		// the beginning of very long articles is the best we can do here
+		content = chunks[0]
+	}
+
+	history := a.History(userID, entryID)
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: qaPrompt},
+		{Role: llm.RoleSystem, Content: fmt.Sprintf("Title: %s\n\n%s", title, content)},
+	}
+	messages = append(messages, history...)
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: question})
+	resp, err := a.cl.Complete(ctx, llm.Request{Messages: messages})
+	if err != nil {
+		return "", err
+	}
+
+	history = append(history,
+		llm.Message{Role: llm.RoleUser, Content: question},
+		llm.Message{Role: llm.RoleAssistant, Content: resp.Content},
+	)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	err = a.store.Put(historyBucket, historyKey(userID, entryID), conversation{Messages: history, UpdatedAt: a.now()})
+	if err != nil {
+		return resp.Content, err
+	}
+	if err := a.prune(); err != nil {
+		log.Printf("error on dropping old conversations: %v\n", err)
+	}
+	return resp.Content, nil
+}
+
+// History returns previous questions and answers, oldest first.
+func (a *Answerer) History(userID int64, entryID int) []llm.Message {
+	var c conversation
+	if _, err := a.store.Get(historyBucket, historyKey(userID, entryID), &c); err != nil {
+		return nil
+	}
+	return c.Messages
+}
+
+// prune drops least recently updated conversations above maxConversations.
+func (a *Answerer) prune() error {
+	keys := a.store.Keys(historyBucket)
+	if len(keys) <= maxConversations {
+		return nil
+	}
+	updated := make(map[string]time.Time, len(keys))
+	for _, key := range keys {
+		var c conversation
+		if _, err := a.store.Get(historyBucket, key, &c); err != nil {
+			return err
+		}
+		updated[key] = c.UpdatedAt
+	}
+	slices.SortStableFunc(keys, func(x, y string) int {
+		return updated[x].Compare(updated[y])
+	})
+	for _, key := range keys[:len(keys)-maxConversations] {
+		if err := a.store.Delete(historyBucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func historyKey(userID int64, entryID int) string {
+	return fmt.Sprintf("%d:%d", userID, entryID)
+}
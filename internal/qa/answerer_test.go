@@ -0,0 +1,80 @@
+package qa
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+type fakeCompleter struct {
+	requests []llm.Request
+}
+
+func (f *fakeCompleter) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	f.requests = append(f.requests, req)
+	return llm.Response{Content: "Answer", Provider: "fake"}, nil
+}
+
+func TestAnswererKeepsHistory(t *testing.T) {
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl := &fakeCompleter{}
+	answerer := NewAnswerer(cl, store, extract.Options{})
+
+	for i := 0; i < 5; i++ {
+		answer, err := answerer.Ask(context.Background(), 1, 42, "Title", "<p>Article body</p>", "Question?")
+		if err != nil {
+			t.Fatalf("Unexpected error during %s", err)
+		}
+		if answer != "Answer" {
+			t.Errorf("Unexpected answer %s", answer)
+		}
+	}
+	first := cl.requests[0].Messages
+	if len(first) != 3 || !strings.Contains(first[1].Content, "Article body") || strings.Contains(first[1].Content, "<p>") {
+		t.Errorf("Unexpected first request %v", first)
+	}
+	second := cl.requests[1].Messages
+	if len(second) != 5 || second[2].Role != llm.RoleUser || second[3].Role != llm.RoleAssistant {
+		t.Errorf("History was not sent %v", second)
+	}
+	if history := answerer.History(1, 42); len(history) != maxHistory {
+		t.Errorf("History is not limited: %d", len(history))
+	}
+	if history := answerer.History(2, 42); len(history) != 0 {
+		t.Errorf("History leaked to another user %v", history)
+	}
+}
+
+func TestAnswererDropsOldConversations(t *testing.T) {
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	answerer := NewAnswerer(&fakeCompleter{}, store, extract.Options{})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	answerer.now = func() time.Time { return now }
+
+	for entryID := 1; entryID <= maxConversations+1; entryID++ {
+		now = now.Add(time.Minute)
+		if _, err := answerer.Ask(context.Background(), 1, entryID, "Title", "<p>Article body</p>", "Question?"); err != nil {
+			t.Fatalf("Unexpected error during %s", err)
+		}
+	}
+	if keys := store.Keys(historyBucket); len(keys) != maxConversations {
+		t.Errorf("Conversations are not limited: %d", len(keys))
+	}
+	if history := answerer.History(1, 1); len(history) != 0 {
+		t.Errorf("The oldest conversation should be dropped, got %v", history)
+	}
+	if history := answerer.History(1, maxConversations+1); len(history) != 2 {
+		t.Errorf("The latest conversation should be kept, got %v", history)
+	}
+}
@@ -0,0 +1,219 @@
+package reader
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MessageLimit is the maximum length of a Telegram text message.
+const MessageLimit = 4096
+
+// Paginate splits Telegram HTML into pages not longer than limit.
+// Pages are cut on paragraph, line or word boundaries when possible and
+// never inside a tag or an entity: tags left open at the end of a page are
+// closed and opened again at the beginning of the next one.
+func Paginate(text string, limit int) []string {
+	p := paginator{limit: limit}
+	for _, tok := range tokenize(text) {
+		if tok.tag {
+			p.addTag(tok.value)
+		} else {
+			p.addText(tok.value)
+		}
+	}
+	p.flush()
+	return p.pages
+}
+
+type token struct {
+	value string
+	tag   bool
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	for s != "" {
+		start := strings.IndexByte(s, '<')
+		if start != 0 {
+			if start < 0 {
+				start = len(s)
+			}
+			tokens = append(tokens, token{value: s[:start]})
+			s = s[start:]
+			continue
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			tokens = append(tokens, token{value: s})
+			break
+		}
+		tokens = append(tokens, token{value: s[:end+1], tag: true})
+		s = s[end+1:]
+	}
+	return tokens
+}
+
+type openTag struct {
+	name string
+	raw  string
+}
+
+type paginator struct {
+	limit int
+	pages []string
+	page  strings.Builder
+	size  int
+	open  []openTag
+	// hasText is false while the page holds only reopened tags
+	hasText bool
+}
+
+func (p *paginator) closingSize() int {
+	size := 0
+	for _, t := range p.open {
+		size += len(t.name) + 3
+	}
+	return size
+}
+
+func (p *paginator) write(s string) {
+	p.page.WriteString(s)
+	p.size += textLen(s)
+}
+
+func (p *paginator) addTag(tag string) {
+	name := tagName(tag)
+	if strings.HasPrefix(tag, "</") {
+		for i := len(p.open) - 1; i >= 0; i-- {
+			if p.open[i].name == name {
+				p.open = append(p.open[:i], p.open[i+1:]...)
+				break
+			}
+		}
+		p.write(tag)
+		return
+	}
+	// leave room for the tag itself, its closing pair and at least some text
+	need := textLen(tag) + len(name) + 3 + 1
+	if p.hasText && p.size+p.closingSize()+need > p.limit {
+		p.breakPage()
+	}
+	p.write(tag)
+	p.open = append(p.open, openTag{name: name, raw: tag})
+}
+
+func (p *paginator) addText(text string) {
+	for text != "" {
+		avail := p.limit - p.size - p.closingSize()
+		if textLen(text) <= avail {
+			p.write(text)
+			p.hasText = p.hasText || strings.TrimSpace(text) != ""
+			return
+		}
+		cut := cutPoint(text, avail)
+		if cut == 0 && !p.hasText {
+			// nothing fits even on an empty page, force progress
+			cut = hardCut(text, max(avail, 1))
+			if cut == 0 {
+				cut = minimalCut(text)
+			}
+		}
+		if cut > 0 {
+			p.write(strings.TrimRight(text[:cut], " \n"))
+			p.hasText = true
+			text = strings.TrimLeft(text[cut:], " \n")
+		}
+		p.breakPage()
+	}
+}
+
+func (p *paginator) breakPage() {
+	for i := len(p.open) - 1; i >= 0; i-- {
+		p.write("</" + p.open[i].name + ">")
+	}
+	p.pages = append(p.pages, strings.TrimSpace(p.page.String()))
+	p.page.Reset()
+	p.size = 0
+	p.hasText = false
+	for _, t := range p.open {
+		p.write(t.raw)
+	}
+}
+
+func (p *paginator) flush() {
+	if p.hasText {
+		p.breakPage()
+	}
+}
+
+// cutPoint finds the byte offset where text should be split so that the
+// head fits into avail, preferring paragraph, line and word boundaries in
+// that order. Zero means that nothing reasonable fits.
+func cutPoint(text string, avail int) int {
+	if avail <= 0 {
+		return 0
+	}
+	head := text[:hardCut(text, avail)]
+	for _, sep := range []string{"\n\n", "\n", " "} {
+		if i := strings.LastIndex(head, sep); i > 0 && textLen(head[:i]) >= avail/2 {
+			return i
+		}
+	}
+	if i := strings.LastIndex(head, " "); i > 0 {
+		return i
+	}
+	return len(head)
+}
+
+// hardCut returns the largest byte offset that fits into avail without
+// splitting a rune or an HTML entity.
+func hardCut(text string, avail int) int {
+	size := 0
+	cut := 0
+	for i, r := range text {
+		size += utf16Len(r)
+		if size > avail {
+			break
+		}
+		cut = i + len(string(r))
+	}
+	if amp := strings.LastIndexByte(text[:cut], '&'); amp >= 0 && !strings.Contains(text[amp:cut], ";") {
+		cut = amp
+	}
+	return cut
+}
+
+// minimalCut returns the length of the first rune or entity of text.
+func minimalCut(text string) int {
+	if text[0] == '&' {
+		if i := strings.IndexByte(text, ';'); i > 0 {
+			return i + 1
+		}
+	}
+	_, size := utf8.DecodeRuneInString(text)
+	return size
+}
+
+func tagName(tag string) string {
+	name := strings.Trim(tag, "</>")
+	if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// textLen measures length the way Telegram does, in UTF-16 code units.
+func textLen(s string) int {
+	size := 0
+	for _, r := range s {
+		size += utf16Len(r)
+	}
+	return size
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
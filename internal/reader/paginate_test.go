@@ -0,0 +1,64 @@
+package reader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	content := `<h2>Intro</h2><p>Some <strong>bold</strong> &amp; <a href="https://example.com">link</a>.</p>
+<ul><li>one</li><li>two</li></ul><img src="https://example.com/a.png" alt="chart"><script>alert(1)</script>
+<pre>if a &lt; b {
+}</pre>`
+
+	rendered := Render(content)
+
+	for _, expected := range []string{
+		"<b>Intro</b>",
+		"Some <b>bold</b> &amp; <a href=\"https://example.com\">link</a>.",
+		"• one\n• two",
+		`<a href="https://example.com/a.png">🖼 chart</a>`,
+		"<pre>if a &lt; b {\n}</pre>",
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("Rendered text does not contain %q:\n%s", expected, rendered)
+		}
+	}
+	if strings.Contains(rendered, "alert") {
+		t.Errorf("Script was not dropped:\n%s", rendered)
+	}
+}
+
+func TestPaginateKeepsTagsBalanced(t *testing.T) {
+	text := "<b>" + strings.Repeat("word &amp; ", 100) + "</b>\n\n" + strings.Repeat("tail ", 50)
+	limit := 120
+
+	pages := Paginate(text, limit)
+	if len(pages) < 2 {
+		t.Fatalf("Expected several pages, got %d", len(pages))
+	}
+	for i, page := range pages {
+		if textLen(page) > limit {
+			t.Errorf("Page %d is longer than limit: %d", i, textLen(page))
+		}
+		if strings.Count(page, "<b>") != strings.Count(page, "</b>") {
+			t.Errorf("Page %d has unbalanced tags: %s", i, page)
+		}
+		if amp := strings.LastIndex(page, "&"); amp >= 0 && !strings.Contains(page[amp:], ";") {
+			t.Errorf("Page %d breaks an entity: %s", i, page)
+		}
+	}
+	if strings.Count(strings.Join(pages, ""), "word") != 100 {
+		t.Errorf("Some text was lost during pagination")
+	}
+}
+
+func TestPaginateShortText(t *testing.T) {
+	pages := Paginate("<i>short</i>", MessageLimit)
+	if len(pages) != 1 || pages[0] != "<i>short</i>" {
+		t.Errorf("Unexpected pages %v", pages)
+	}
+	if pages := Paginate("", MessageLimit); len(pages) != 0 {
+		t.Errorf("Expected no pages for empty text, got %v", pages)
+	}
+}
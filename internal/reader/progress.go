@@ -0,0 +1,39 @@
+package reader
+
+import (
+	"fmt"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+const progressBucket = "reading_progress"
+
+// PageLimit leaves room for the page header inside a Telegram message.
+const PageLimit = MessageLimit - 256
+
+// Progress remembers the last page each user has read per entry.
+type Progress struct {
+	store *storage.Store
+}
+
+func NewProgress(store *storage.Store) *Progress {
+	return &Progress{store: store}
+}
+
+// Position returns the saved page index, zero when the entry was never opened.
+func (p *Progress) Position(userID int64, entryID int) int {
+	var page int
+	_, err := p.store.Get(progressBucket, progressKey(userID, entryID), &page)
+	if err != nil {
+		return 0
+	}
+	return page
+}
+
+func (p *Progress) SetPosition(userID int64, entryID int, page int) error {
+	return p.store.Put(progressBucket, progressKey(userID, entryID), page)
+}
+
+func progressKey(userID int64, entryID int) string {
+	return fmt.Sprintf("%d:%d", userID, entryID)
+}
@@ -0,0 +1,187 @@
+package reader
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/htmltext"
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Render converts cleaned article HTML stored by wallabag into the subset of
+// HTML understood by Telegram (b, i, u, s, a, code, pre, blockquote).
+// Headings become bold lines, lists become bullet lines and images are
+// replaced by links, everything else is flattened to text.
+func Render(content string) string {
+	root, err := xhtml.Parse(strings.NewReader(content))
+	if err != nil {
+		return html.EscapeString(content)
+	}
+	r := &renderer{}
+	r.walk(root)
+	return cleanup(r.sb.String())
+}
+
+type renderer struct {
+	sb    htmltext.Builder
+	lists []listState
+}
+
+type listState struct {
+	ordered bool
+	index   int
+}
+
+var inlineTags = map[atom.Atom]string{
+	atom.B:      "b",
+	atom.Strong: "b",
+	atom.I:      "i",
+	atom.Em:     "i",
+	atom.Cite:   "i",
+	atom.U:      "u",
+	atom.Ins:    "u",
+	atom.S:      "s",
+	atom.Strike: "s",
+	atom.Del:    "s",
+}
+
+func (r *renderer) walk(n *xhtml.Node) {
+	switch n.Type {
+	case xhtml.TextNode:
+		r.sb.WriteText(n.Data, html.EscapeString)
+		return
+	case xhtml.ElementNode:
+	default:
+		r.children(n)
+		return
+	}
+
+	if tag, ok := inlineTags[n.DataAtom]; ok {
+		r.wrap(n, tag)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Svg, atom.Head, atom.Form, atom.Button:
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.sb.Block()
+		r.wrap(n, "b")
+		r.sb.Block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Figure, atom.Table:
+		r.sb.Block()
+		r.children(n)
+		r.sb.Block()
+	case atom.Br:
+		r.sb.WriteString("\n")
+	case atom.Hr:
+		r.sb.Block()
+		r.sb.WriteString("———")
+		r.sb.Block()
+	case atom.Tr:
+		r.sb.Newline()
+		r.children(n)
+	case atom.Td, atom.Th:
+		r.children(n)
+		r.sb.WriteString(" | ")
+	case atom.Figcaption:
+		r.sb.Newline()
+		r.wrap(n, "i")
+		r.sb.Newline()
+	case atom.Blockquote:
+		r.sb.Block()
+		r.wrap(n, "blockquote")
+		r.sb.Block()
+	case atom.Pre:
+		r.sb.Block()
+		r.sb.WriteString("<pre>")
+		r.sb.WriteString(html.EscapeString(strings.Trim(htmltext.TextContent(n), "\n")))
+		r.sb.WriteString("</pre>")
+		r.sb.Block()
+	case atom.Code, atom.Kbd, atom.Samp:
+		r.sb.WriteString("<code>")
+		r.sb.WriteString(html.EscapeString(htmltext.TextContent(n)))
+		r.sb.WriteString("</code>")
+	case atom.A:
+		href, ok := absoluteURL(htmltext.Attr(n, "href"))
+		if !ok {
+			r.children(n)
+			return
+		}
+		r.sb.WriteString(fmt.Sprintf(`<a href="%s">`, html.EscapeString(href)))
+		r.children(n)
+		r.sb.WriteString("</a>")
+	case atom.Img:
+		src, ok := absoluteURL(htmltext.Attr(n, "src"))
+		if !ok {
+			return
+		}
+		alt := strings.TrimSpace(htmltext.Attr(n, "alt"))
+		if alt == "" {
+			alt = "image"
+		}
+		r.sb.WriteString(fmt.Sprintf(`<a href="%s">🖼 %s</a>`, html.EscapeString(src), html.EscapeString(alt)))
+	case atom.Iframe, atom.Video, atom.Audio:
+		src, ok := absoluteURL(htmltext.Attr(n, "src"))
+		if !ok {
+			return
+		}
+		r.sb.Newline()
+		r.sb.WriteString(fmt.Sprintf(`<a href="%s">▶ embedded media</a>`, html.EscapeString(src)))
+		r.sb.Newline()
+	case atom.Ul, atom.Ol:
+		r.sb.Newline()
+		r.lists = append(r.lists, listState{ordered: n.DataAtom == atom.Ol})
+		r.children(n)
+		r.lists = r.lists[:len(r.lists)-1]
+		r.sb.Newline()
+	case atom.Li:
+		r.sb.Newline()
+		depth := len(r.lists)
+		if depth > 0 {
+			r.sb.WriteString(strings.Repeat("  ", depth-1))
+			list := &r.lists[depth-1]
+			list.index++
+			if list.ordered {
+				r.sb.WriteString(fmt.Sprintf("%d. ", list.index))
+			} else {
+				r.sb.WriteString("• ")
+			}
+		} else {
+			r.sb.WriteString("• ")
+		}
+		r.children(n)
+	default:
+		r.children(n)
+	}
+}
+
+func (r *renderer) children(n *xhtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+func (r *renderer) wrap(n *xhtml.Node, tag string) {
+	r.sb.WriteString("<" + tag + ">")
+	r.children(n)
+	r.sb.WriteString("</" + tag + ">")
+}
+
+func absoluteURL(raw string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	return u.String(), true
+}
+
+var emptyTags = regexp.MustCompile(`<(b|i|u|s|blockquote)>\s*</(b|i|u|s|blockquote)>`)
+
+func cleanup(s string) string {
+	return htmltext.Cleanup(emptyTags.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Rule assigns tags and state to entries matching all of its conditions.
+type Rule struct {
+	Name           string   `mapstructure:"name" json:"name"`
+	Domain         string   `mapstructure:"domain" json:"domain"`
+	URLRegex       string   `mapstructure:"url_regex" json:"url_regex"`
+	TitleRegex     string   `mapstructure:"title_regex" json:"title_regex"`
+	MinReadingTime int      `mapstructure:"min_reading_time" json:"min_reading_time"`
+	MaxReadingTime int      `mapstructure:"max_reading_time" json:"max_reading_time"`
+	Tags           []string `mapstructure:"tags" json:"tags"`
+	Archive        bool     `mapstructure:"archive" json:"archive"`
+	Star           bool     `mapstructure:"star" json:"star"`
+}
+
+// Entry is what rules are matched against.
+type Entry struct {
+	URL   string
+	Title string
+	// ReadingTime in minutes, zero when unknown
+	ReadingTime int
+}
+
+type Result struct {
+	Tags    []string
+	Archive bool
+	Star    bool
+	// Matched holds names of rules which were applied
+	Matched []string
+}
+
+type compiledRule struct {
+	Rule
+	urlRe   *regexp.Regexp
+	titleRe *regexp.Regexp
+}
+
+type Engine struct {
+	rules []compiledRule
+}
+
+func NewEngine(rules []Rule) (*Engine, error) {
+	e := &Engine{}
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule #%d", i+1)
+		}
+		if r.Domain == "" && r.URLRegex == "" && r.TitleRegex == "" && r.MinReadingTime == 0 && r.MaxReadingTime == 0 {
+			return nil, fmt.Errorf("%s has no conditions", r.Name)
+		}
+		if len(r.Tags) == 0 && !r.Archive && !r.Star {
+			return nil, fmt.Errorf("%s has no actions", r.Name)
+		}
+		cr := compiledRule{Rule: r}
+		var err error
+		if r.URLRegex != "" {
+			cr.urlRe, err = regexp.Compile(r.URLRegex)
+			if err != nil {
+				return nil, errors.Join(fmt.Errorf("%s has invalid url_regex", r.Name), err)
+			}
+		}
+		if r.TitleRegex != "" {
+			cr.titleRe, err = regexp.Compile(r.TitleRegex)
+			if err != nil {
+				return nil, errors.Join(fmt.Errorf("%s has invalid title_regex", r.Name), err)
+			}
+		}
+		e.rules = append(e.rules, cr)
+	}
+	return e, nil
+}
+
+func (e *Engine) Rules() []Rule {
+	rules := make([]Rule, len(e.rules))
+	for i, r := range e.rules {
+		rules[i] = r.Rule
+	}
+	return rules
+}
+
+// Apply merges actions of every matching rule.
+func (e *Engine) Apply(entry Entry) Result {
+	var result Result
+	for _, r := range e.rules {
+		if !r.matches(entry) {
+			continue
+		}
+		result.Matched = append(result.Matched, r.Name)
+		for _, tag := range r.Tags {
+			if !slices.Contains(result.Tags, tag) {
+				result.Tags = append(result.Tags, tag)
+			}
+		}
+		result.Archive = result.Archive || r.Archive
+		result.Star = result.Star || r.Star
+	}
+	return result
+}
+
+func (r compiledRule) matches(entry Entry) bool {
+	if r.Domain != "" && !matchDomain(entry.URL, r.Domain) {
+		return false
+	}
+	if r.urlRe != nil && !r.urlRe.MatchString(entry.URL) {
+		return false
+	}
+	if r.titleRe != nil && !r.titleRe.MatchString(entry.Title) {
+		return false
+	}
+	if r.MinReadingTime > 0 && entry.ReadingTime < r.MinReadingTime {
+		return false
+	}
+	if r.MaxReadingTime > 0 && (entry.ReadingTime == 0 || entry.ReadingTime > r.MaxReadingTime) {
+		return false
+	}
+	return true
+}
+
+// matchDomain accepts the domain itself and any of its subdomains.
+func matchDomain(rawURL string, domain string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	domain = strings.ToLower(domain)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// String describes the rule in one line for chat listings.
+func (r Rule) String() string {
+	var conditions []string
+	if r.Domain != "" {
+		conditions = append(conditions, "domain "+r.Domain)
+	}
+	if r.URLRegex != "" {
+		conditions = append(conditions, "url ~ "+r.URLRegex)
+	}
+	if r.TitleRegex != "" {
+		conditions = append(conditions, "title ~ "+r.TitleRegex)
+	}
+	if r.MinReadingTime > 0 || r.MaxReadingTime > 0 {
+		conditions = append(conditions, fmt.Sprintf("reading time %d-%d min", r.MinReadingTime, r.MaxReadingTime))
+	}
+	var actions []string
+	if len(r.Tags) > 0 {
+		actions = append(actions, "tags "+strings.Join(r.Tags, ", "))
+	}
+	if r.Archive {
+		actions = append(actions, "archive")
+	}
+	if r.Star {
+		actions = append(actions, "star")
+	}
+	return fmt.Sprintf("%s: %s → %s", r.Name, strings.Join(conditions, " and "), strings.Join(actions, ", "))
+}
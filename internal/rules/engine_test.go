@@ -0,0 +1,60 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEngineApply(t *testing.T) {
+	engine, err := NewEngine([]Rule{
+		{Name: "repos", Domain: "github.com", Tags: []string{"repo"}},
+		{Name: "videos", Domain: "youtube.com", Tags: []string{"video"}, Archive: true},
+		{Name: "specs", TitleRegex: `\bRFC\b`, Tags: []string{"spec"}, Star: true},
+		{Name: "longreads", MinReadingTime: 30, Tags: []string{"longread"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		entry   Entry
+		matched []string
+		tags    []string
+		archive bool
+		star    bool
+	}{
+		{"domain", Entry{URL: "https://github.com/vanadium23/wallabag-telegram-bot"}, []string{"repos"}, []string{"repo"}, false, false},
+		{"subdomain", Entry{URL: "https://www.youtube.com/watch?v=1"}, []string{"videos"}, []string{"video"}, true, false},
+		{"not a subdomain", Entry{URL: "https://notgithub.com/"}, nil, nil, false, false},
+		{"title", Entry{URL: "https://github.com/x", Title: "RFC 9110 notes", ReadingTime: 40}, []string{"repos", "specs", "longreads"}, []string{"repo", "spec", "longread"}, false, true},
+		{"unknown reading time", Entry{URL: "https://example.com"}, nil, nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := engine.Apply(tt.entry)
+			if !slices.Equal(result.Matched, tt.matched) {
+				t.Errorf("Unexpected matched rules %v", result.Matched)
+			}
+			if !slices.Equal(result.Tags, tt.tags) {
+				t.Errorf("Unexpected tags %v", result.Tags)
+			}
+			if result.Archive != tt.archive || result.Star != tt.star {
+				t.Errorf("Unexpected archive %t and star %t", result.Archive, result.Star)
+			}
+		})
+	}
+}
+
+func TestNewEngineValidation(t *testing.T) {
+	invalid := [][]Rule{
+		{{Name: "empty", Tags: []string{"x"}}},
+		{{Name: "no actions", Domain: "example.com"}},
+		{{Name: "bad regex", URLRegex: "(", Tags: []string{"x"}}},
+	}
+	for _, rules := range invalid {
+		if _, err := NewEngine(rules); err == nil {
+			t.Errorf("Expected error for %s", rules[0].Name)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// flushDelay batches writes coming in quick succession, like usage records
+// of a single request, into one rewrite of the file.
+const flushDelay = 2 * time.Second
+
+// Store is a small bucketed key-value store persisted as a single JSON file.
+// It keeps the bot's local state (reading positions, caches, settings)
+// without requiring a database next to wallabag.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	buckets map[string]map[string]json.RawMessage
+	// pending is the scheduled flush, nil when the file is up to date
+	pending *time.Timer
+}
+
+// NewStore opens the store at path, creating it on first write.
+// An empty path gives an in-memory store which is never persisted.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		buckets: map[string]map[string]json.RawMessage{},
+	}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.buckets); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get decodes the value stored under bucket/key into v.
+// It reports false when there is no such value.
+func (s *Store) Get(bucket, key string, v any) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.buckets[bucket][key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// Put stores v under bucket/key, the store is flushed to disk shortly after.
+func (s *Store) Put(bucket, key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = map[string]json.RawMessage{}
+	}
+	s.buckets[bucket][key] = raw
+	s.scheduleFlush()
+	return nil
+}
+
+// PutMany stores several values of the bucket at once.
+func (s *Store) PutMany(bucket string, values map[string]any) error {
+	raws := make(map[string]json.RawMessage, len(values))
+	for key, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		raws[key] = raw
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = map[string]json.RawMessage{}
+	}
+	for key, raw := range raws {
+		s.buckets[bucket][key] = raw
+	}
+	s.scheduleFlush()
+	return nil
+}
+
+// Delete removes bucket/key, it is not an error if the key is missing.
+func (s *Store) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket][key]; !ok {
+		return nil
+	}
+	delete(s.buckets[bucket], key)
+	s.scheduleFlush()
+	return nil
+}
+
+// Keys returns sorted keys of the bucket.
+func (s *Store) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for k := range s.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Close writes pending changes to disk, the store should not be used after.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == nil {
+		return nil
+	}
+	s.pending.Stop()
+	s.pending = nil
+	return s.flush()
+}
+
+func (s *Store) scheduleFlush() {
+	if s.path == "" || s.pending != nil {
+		return
+	}
+	s.pending = time.AfterFunc(flushDelay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.pending == nil {
+			// flushed by Close
+			return
+		}
+		s.pending = nil
+		if err := s.flush(); err != nil {
+			log.Printf("error on flushing storage %s: %v\n", s.path, err)
+		}
+	})
+}
+
+// flush writes the store to a temporary file and renames it over the
+// previous version, so a crash never leaves a half-written file behind.
+func (s *Store) flush() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.buckets)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreFlushesOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range []string{"a", "b", "c"} {
+		if err := s.Put("bucket", key, i); err != nil {
+			t.Fatalf("Unexpected error during %s", err)
+		}
+	}
+	if err := s.Delete("bucket", "b"); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Writes should be batched, file is already written: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value int
+	if ok, err := reopened.Get("bucket", "c", &value); !ok || err != nil || value != 2 {
+		t.Errorf("Unexpected value %d, %v, %v", value, ok, err)
+	}
+	if keys := reopened.Keys("bucket"); len(keys) != 2 {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+}
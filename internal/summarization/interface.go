@@ -1,29 +1,31 @@
 package summarization
 
-import "net/url"
+import "context"
 
 type Summarizer interface {
-	Summarize(title, content string) (string, error)
+	Summarize(ctx context.Context, title, content string, opts Options) (string, error)
 }
 
+// summarizationPrompt is filled by Options.prompt with language, length
+// and format instructions.
 const summarizationPrompt = `
 Your task is to create a concise and informative summary of the given article. Follow these guidelines:
-1. The summary should be in English, even if the article is in another language.
-2. Keep the summary between 100-200 words.
+1. %s
+2. %s
 3. Focus on the main points and key takeaways.
 4. Maintain a professional and objective tone.
 5. Avoid personal opinions or commentary.
 6. Ensure the summary is coherent and well-structured.
 
-The summary should be returned as plain text, without any additional formatting or markdown.
+%s
 `
 
-func NewSummarizer(
-	openaiApiKey string, proxyUrl *url.URL,
-	openrouterApiKey string, openrouterModel string,
-) Summarizer {
-	if openrouterApiKey != "" {
-		return NewOpenrouterSummarizer(openrouterApiKey, proxyUrl, openrouterModel)
-	}
-	return NewOpenaiSummarizer(openaiApiKey, proxyUrl)
-}
+// chunkPrompt is used for parts of articles too long for a single request.
+const chunkPrompt = `
+You are given one part of a longer article. Extract its main points and key facts as a short plain text summary of at most 150 words, in English. Do not add an introduction or conclusion, other parts are summarized separately.
+`
+
+// partialPrompt is added when some content didn't make it into summary.
+const partialPrompt = `
+Some parts of the article were shortened or left out because it is too long. End the summary with a separate short line noting that it covers the article only partially, in the same language as the summary.
+`
@@ -0,0 +1,82 @@
+package summarization
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	LengthShort  = "short"
+	LengthMedium = "medium"
+	LengthLong   = "long"
+
+	FormatProse   = "prose"
+	FormatBullets = "bullets"
+	FormatTLDR    = "tldr"
+
+	// LanguageOriginal keeps the language of the article
+	LanguageOriginal = "original"
+)
+
+var (
+	Lengths = []string{LengthShort, LengthMedium, LengthLong}
+	Formats = []string{FormatProse, FormatBullets, FormatTLDR}
+)
+
+var lengthWords = map[string]string{
+	LengthShort:  "50-100",
+	LengthMedium: "100-200",
+	LengthLong:   "250-400",
+}
+
+// Options tune a summary, zero values fall back to DefaultOptions.
+type Options struct {
+	Language string `json:"language"`
+	Length   string `json:"length"`
+	Format   string `json:"format"`
+}
+
+func DefaultOptions() Options {
+	return Options{Language: "English", Length: LengthMedium, Format: FormatProse}
+}
+
+// withDefaults fills empty and unknown values from DefaultOptions.
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if strings.TrimSpace(o.Language) == "" {
+		o.Language = defaults.Language
+	}
+	if !slices.Contains(Lengths, o.Length) {
+		o.Length = defaults.Length
+	}
+	if !slices.Contains(Formats, o.Format) {
+		o.Format = defaults.Format
+	}
+	return o
+}
+
+func (o Options) prompt() string {
+	o = o.withDefaults()
+
+	language := fmt.Sprintf("The summary should be in %s, even if the article is in another language.", o.Language)
+	if strings.EqualFold(o.Language, LanguageOriginal) {
+		language = "The summary should be in the language of the article."
+	}
+	length := fmt.Sprintf("Keep the summary between %s words.", lengthWords[o.Length])
+	format := "The summary should be returned as plain text, without any additional formatting or markdown."
+	switch o.Format {
+	case FormatBullets:
+		format = "The summary should be returned as 3-7 bullet points, each on its own line starting with \"• \", without any other formatting or markdown."
+	case FormatTLDR:
+		length = "Keep the summary to a single sentence of at most 40 words."
+		format = "The summary should be returned as one plain text sentence (TL;DR), without any additional formatting or markdown."
+	}
+	return fmt.Sprintf(summarizationPrompt, language, length, format)
+}
+
+// String describes options for chat.
+func (o Options) String() string {
+	o = o.withDefaults()
+	return fmt.Sprintf("language: %s, length: %s, format: %s", o.Language, o.Length, o.Format)
+}
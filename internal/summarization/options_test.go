@@ -0,0 +1,49 @@
+package summarization
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+func TestOptionsPrompt(t *testing.T) {
+	cases := []struct {
+		opts     Options
+		contains []string
+	}{
+		{Options{}, []string{"in English", "100-200 words", "plain text"}},
+		{Options{Language: "German", Length: LengthShort, Format: FormatBullets}, []string{"in German", "50-100 words", "bullet points"}},
+		{Options{Language: LanguageOriginal, Format: FormatTLDR}, []string{"language of the article", "single sentence"}},
+		{Options{Length: "huge", Format: "poem"}, []string{"100-200 words", "plain text"}},
+	}
+	for _, c := range cases {
+		prompt := c.opts.prompt()
+		for _, s := range c.contains {
+			if !strings.Contains(prompt, s) {
+				t.Errorf("Prompt for %+v misses %q", c.opts, s)
+			}
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := NewSettings(store)
+	if opts := settings.Get(1); opts != DefaultOptions() {
+		t.Errorf("Unexpected options for new user %+v", opts)
+	}
+	chosen := Options{Language: "Russian", Length: LengthLong, Format: FormatTLDR}
+	if err := settings.Set(1, chosen); err != nil {
+		t.Fatal(err)
+	}
+	if opts := settings.Get(1); opts != chosen {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+	if opts := settings.Get(2); opts != DefaultOptions() {
+		t.Errorf("Settings leaked to another user %+v", opts)
+	}
+}
@@ -0,0 +1,31 @@
+package summarization
+
+import (
+	"strconv"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+const settingsBucket = "summary_settings"
+
+// Settings keeps summary options chosen by each user.
+type Settings struct {
+	store *storage.Store
+}
+
+func NewSettings(store *storage.Store) *Settings {
+	return &Settings{store: store}
+}
+
+// Get returns options of the user, defaults when nothing was chosen.
+func (s *Settings) Get(userID int64) Options {
+	var opts Options
+	if _, err := s.store.Get(settingsBucket, strconv.FormatInt(userID, 10), &opts); err != nil {
+		return DefaultOptions()
+	}
+	return opts.withDefaults()
+}
+
+func (s *Settings) Set(userID int64, opts Options) error {
+	return s.store.Put(settingsBucket, strconv.FormatInt(userID, 10), opts.withDefaults())
+}
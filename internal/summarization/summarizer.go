@@ -0,0 +1,117 @@
+package summarization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+)
+
+const (
+	// reservedTokens are left in the context window for the answer
+	reservedTokens = 1024
+	// maxChunks bounds requests per article, the rest of very long
+	// content is dropped
+	maxChunks = 24
+	// bytesPerToken converts token budget to bytes for llm.Truncate, it is
+	// about the same for latin and other scripts
+	bytesPerToken = 4
+)
+
+// LLMSummarizer summarizes articles with any llm provider. Articles which
+// don't fit into the model context are summarized by parts first.
+type LLMSummarizer struct {
+	cl   llm.ChatCompleter
+	text extract.Options
+}
+
+// NewSummarizer creates summarizer on top of completer, nil completer gives
+// a summarizer which always fails, as there is no provider configured.
+// Content HTML is converted to text with the given options.
+func NewSummarizer(cl llm.ChatCompleter, text extract.Options) Summarizer {
+	return LLMSummarizer{cl: cl, text: text}
+}
+
+func (summarizer LLMSummarizer) Summarize(ctx context.Context, title, content string, opts Options) (string, error) {
+	if summarizer.cl == nil {
+		return "", errors.New("llm provider was not configured for summarization system")
+	}
+	content = extract.Text(content, summarizer.text)
+	if content == "" {
+		return "", errors.New("no content -> no summary")
+	}
+	prompt := opts.prompt()
+	budget := summarizer.budget(prompt, title)
+	chunks := llm.Split(content, budget)
+	partial := false
+	if len(chunks) > maxChunks {
+		fmt.Printf("Summarization of %q uses first %d of %d chunks\n", title, maxChunks, len(chunks))
+		chunks = chunks[:maxChunks]
+		partial = true
+	}
+	// map: every part is summarized on its own, reduce: summaries of parts
+	// are merged, again by groups if they don't fit together
+	for len(chunks) > 1 {
+		summaries := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			summary, err := summarizer.complete(ctx, chunkPrompt, title, fmt.Sprintf("Part %d of %d: %s", i+1, len(chunks), chunk))
+			if err != nil {
+				return "", err
+			}
+			summaries[i] = summary
+		}
+		merged := llm.Split(strings.Join(summaries, "\n\n"), budget)
+		if len(merged) >= len(chunks) {
+			// summaries of parts are as long as parts and another round
+			// won't shrink them, so every part gets its share of the budget
+			fmt.Printf("Summarization of %q shortens summaries of %d parts\n", title, len(summaries))
+			share := budget * bytesPerToken / len(summaries)
+			for i := range summaries {
+				summaries[i] = llm.Truncate(summaries[i], share)
+			}
+			merged = []string{strings.Join(summaries, "\n\n")}
+			partial = true
+		}
+		chunks = merged
+	}
+	if partial {
+		// the model knows the language of the summary, so it writes the note
+		prompt += partialPrompt
+	}
+	return summarizer.complete(ctx, prompt, title, fmt.Sprintf("Content of article: %s", chunks[0]))
+}
+
+// budget is how many tokens of content fit into a single request.
+func (summarizer LLMSummarizer) budget(prompt, title string) int {
+	overhead := llm.EstimateTokens(prompt) + llm.EstimateTokens(title) + reservedTokens
+	return max(llm.WindowOf(summarizer.cl)-overhead, reservedTokens)
+}
+
+func (summarizer LLMSummarizer) complete(ctx context.Context, prompt, title, content string) (string, error) {
+	resp, err := summarizer.cl.Complete(ctx, llm.Request{
+		Messages: []llm.Message{
+			{
+				Role:    llm.RoleSystem,
+				Content: prompt,
+			},
+			{
+				Role:    llm.RoleUser,
+				Content: fmt.Sprintf("Title: %s", title),
+			},
+			{
+				Role:    llm.RoleUser,
+				Content: content,
+			},
+		},
+	})
+	if err != nil {
+		fmt.Printf("Summarization ChatCompletion error: %v\n", err)
+		return "", err
+	}
+
+	fmt.Printf("Summarization ChatCompletion response from %s: %s\n", resp.Provider, resp.Content)
+	return resp.Content, nil
+}
@@ -0,0 +1,118 @@
+package summarization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+)
+
+type fakeCompleter struct {
+	window   int
+	requests []llm.Request
+	// echo answers with the content, like a model which doesn't shorten it
+	echo bool
+}
+
+func (f *fakeCompleter) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	f.requests = append(f.requests, req)
+	if f.echo {
+		return llm.Response{Content: req.Messages[2].Content, Provider: "fake"}, nil
+	}
+	return llm.Response{Content: "Short summary", Provider: "fake"}, nil
+}
+
+func (f *fakeCompleter) ContextWindow() int {
+	return f.window
+}
+
+func TestLLMSummarizerSummarize(t *testing.T) {
+	cl := &fakeCompleter{window: llm.DefaultContextWindow}
+	summarizer := NewSummarizer(cl, extract.Options{})
+
+	content := strings.Repeat("word ", 2000)
+	summary, err := summarizer.Summarize(context.Background(), "Title", content, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if summary != "Short summary" {
+		t.Errorf("Unexpected summary %s", summary)
+	}
+	if len(cl.requests) != 1 {
+		t.Fatalf("Unexpected requests %v", cl.requests)
+	}
+	if !strings.Contains(cl.requests[0].Messages[2].Content, strings.TrimSpace(content)) {
+		t.Errorf("Content fitting the context should be sent whole")
+	}
+}
+
+func TestLLMSummarizerMapReduce(t *testing.T) {
+	cl := &fakeCompleter{window: 2048}
+	summarizer := NewSummarizer(cl, extract.Options{})
+
+	paragraph := strings.Repeat("Кириллица и latin words. ", 100)
+	content := strings.Repeat(paragraph+"\n\n", 10)
+	if _, err := summarizer.Summarize(context.Background(), "Title", content, DefaultOptions()); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if len(cl.requests) < 3 {
+		t.Fatalf("Expected map and reduce requests, got %d", len(cl.requests))
+	}
+	budget := summarizer.(LLMSummarizer).budget(DefaultOptions().prompt(), "Title")
+	for i, req := range cl.requests[:len(cl.requests)-1] {
+		if req.Messages[0].Content != chunkPrompt {
+			t.Errorf("Request %d is not a chunk summary", i)
+		}
+		if tokens := llm.EstimateTokens(req.Messages[2].Content); tokens > budget+10 {
+			t.Errorf("Chunk %d has %d tokens, budget %d", i, tokens, budget)
+		}
+	}
+	final := cl.requests[len(cl.requests)-1]
+	if final.Messages[0].Content != DefaultOptions().prompt() || !strings.Contains(final.Messages[2].Content, "Short summary") {
+		t.Errorf("Unexpected final request %v", final)
+	}
+}
+
+func TestLLMSummarizerShortensParts(t *testing.T) {
+	cl := &fakeCompleter{window: 2048, echo: true}
+	summarizer := NewSummarizer(cl, extract.Options{})
+
+	var content strings.Builder
+	for i := 1; i <= 6; i++ {
+		fmt.Fprintf(&content, "Section %d. %s\n\n", i, strings.Repeat("latin words ", 500))
+	}
+	opts := Options{Language: "Russian"}
+	if _, err := summarizer.Summarize(context.Background(), "Title", content.String(), opts); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	prompt := cl.requests[len(cl.requests)-1].Messages[0].Content
+	if prompt != opts.prompt()+partialPrompt || !strings.Contains(prompt, "Russian") {
+		t.Errorf("Summary should be marked as partial in its language: %s", prompt)
+	}
+	final := cl.requests[len(cl.requests)-1].Messages[2].Content
+	budget := summarizer.(LLMSummarizer).budget(opts.prompt(), "Title")
+	if tokens := llm.EstimateTokens(final); tokens > budget+10 {
+		t.Errorf("Final request has %d tokens, budget %d", tokens, budget)
+	}
+	// every part is still represented, not only the first one
+	for i := 1; i <= 6; i++ {
+		if !strings.Contains(final, fmt.Sprintf("Section %d.", i)) {
+			t.Errorf("Section %d is missing from final request", i)
+		}
+	}
+}
+
+func TestLLMSummarizerSendsText(t *testing.T) {
+	cl := &fakeCompleter{window: llm.DefaultContextWindow}
+	summarizer := NewSummarizer(cl, extract.Options{})
+
+	if _, err := summarizer.Summarize(context.Background(), "Title", `<script>track()</script><h1>Header</h1><p class="lead">Body</p>`, DefaultOptions()); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if content := cl.requests[0].Messages[2].Content; content != "Content of article: # Header\n\nBody" {
+		t.Errorf("Unexpected content %q", content)
+	}
+}
@@ -0,0 +1,81 @@
+package tagging
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MatchTag finds label which differs from tag only in spelling: case,
+// separators, plural form or a typo in a long word. Exact matches win over
+// fuzzy ones.
+func MatchTag(tag string, labels []string) (string, bool) {
+	key := tagKey(tag)
+	if key == "" {
+		return "", false
+	}
+	best, bestDistance := "", maxDistance(key)+1
+	for _, label := range labels {
+		labelKey := tagKey(label)
+		if label == tag || labelKey == key {
+			return label, true
+		}
+		distance := levenshtein(stem(key), stem(labelKey))
+		if distance < bestDistance {
+			best, bestDistance = label, distance
+		}
+	}
+	return best, best != ""
+}
+
+// minFuzzyLength is the shortest tag allowed to differ by a typo, short
+// words have close neighbours with other meaning, like react and reach.
+const minFuzzyLength = 7
+
+// maxDistance allows a typo per five letters of long tags.
+func maxDistance(key string) int {
+	n := len([]rune(key))
+	if n < minFuzzyLength {
+		return 0
+	}
+	return n / 5
+}
+
+// stem drops plural ending, so "boardgames" and "boardgame" share a stem.
+func stem(key string) string {
+	if len(key) > 3 && !strings.HasSuffix(key, "ss") {
+		return strings.TrimSuffix(key, "s")
+	}
+	return key
+}
+
+// tagKey drops case and everything except letters and digits, so
+// "System-Design" and "system design" share a key.
+func tagKey(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, tag)
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,30 @@
+package tagging
+
+import "testing"
+
+func TestMatchTag(t *testing.T) {
+	labels := []string{"golang", "system-design", "boardgames", "k8s", "go", "react", "test", "architecture"}
+	cases := []struct {
+		tag   string
+		label string
+		ok    bool
+	}{
+		{"golang", "golang", true},
+		{"GoLang", "golang", true},
+		{"system design", "system-design", true},
+		{"boardgame", "boardgames", true},
+		{"k9s", "", false},
+		{"reach", "", false},
+		{"tests", "test", true},
+		{"architecure", "architecture", true},
+		{"golang-", "golang", true},
+		{"rust", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		label, ok := MatchTag(c.tag, labels)
+		if ok != c.ok || label != c.label {
+			t.Errorf("MatchTag(%q) = %q, %v; want %q, %v", c.tag, label, ok, c.label, c.ok)
+		}
+	}
+}
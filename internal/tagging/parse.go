@@ -0,0 +1,119 @@
+package tagging
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxTags limits guessed tags, "autotag" is added on top of them
+const maxTags = 5
+
+var (
+	fencedBlock = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*(.*?)```")
+	listMarker  = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+	errNoTags = errors.New("no tags found in response")
+)
+
+// tagsSchema is requested from providers supporting structured output,
+// schema root has to be an object.
+var tagsSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {"tags": {"type": "array", "items": {"type": "string"}}},
+	"required": ["tags"],
+	"additionalProperties": false
+}`)
+
+// parseTags extracts tags from model answer: a JSON array or object, maybe
+// wrapped in code fences and prose, or a plain comma separated list.
+func parseTags(answer string) ([]string, error) {
+	answer = strings.TrimSpace(answer)
+	if m := fencedBlock.FindStringSubmatch(answer); m != nil {
+		answer = strings.TrimSpace(m[1])
+	}
+	if tags, ok := decodeTags([]byte(answer)); ok {
+		return normalizeTags(tags)
+	}
+	if start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]"); start >= 0 && end > start {
+		if tags, ok := decodeTags([]byte(answer[start : end+1])); ok {
+			return normalizeTags(tags)
+		}
+	}
+	if start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}"); start >= 0 && end > start {
+		if tags, ok := decodeTags([]byte(answer[start : end+1])); ok {
+			return normalizeTags(tags)
+		}
+	}
+	if strings.ContainsAny(answer, "[]{}") {
+		return nil, errNoTags
+	}
+	return normalizeTags(splitList(answer))
+}
+
+// decodeTags accepts ["a", "b"], [{"tag": "a"}] and {"tags": [...]}.
+func decodeTags(data []byte) ([]string, bool) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, false
+	}
+	if object, ok := value.(map[string]any); ok {
+		for _, key := range []string{"tags", "values", "labels"} {
+			if list, ok := object[key]; ok {
+				value = list
+				break
+			}
+		}
+	}
+	list, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	var tags []string
+	for _, item := range list {
+		switch item := item.(type) {
+		case string:
+			tags = append(tags, item)
+		case map[string]any:
+			for _, key := range []string{"tag", "name", "label", "value"} {
+				if tag, ok := item[key].(string); ok {
+					tags = append(tags, tag)
+					break
+				}
+			}
+		}
+	}
+	return tags, true
+}
+
+func splitList(answer string) []string {
+	var tags []string
+	for _, line := range strings.Split(answer, "\n") {
+		line = listMarker.ReplaceAllString(line, "")
+		// "Tags: a, b" -> "a, b"
+		if _, rest, ok := strings.Cut(line, ":"); ok {
+			line = rest
+		}
+		tags = append(tags, strings.Split(line, ",")...)
+	}
+	return tags
+}
+
+// normalizeTags lowercases and trims tags, dropping duplicates and
+// anything too long to be a tag.
+func normalizeTags(raw []string) ([]string, error) {
+	var tags []string
+	for _, tag := range raw {
+		tag = normalizeTag(strings.Trim(tag, " \t\"'`#.;"))
+		if tag == "" || len(strings.Fields(tag)) > 3 || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return nil, errNoTags
+	}
+	return tags, nil
+}
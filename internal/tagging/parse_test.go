@@ -0,0 +1,40 @@
+package tagging
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	cases := []struct {
+		name   string
+		answer string
+		tags   []string
+	}{
+		{"array", `["programming", "golang"]`, []string{"programming", "golang"}},
+		{"fenced", "```json\n[\"Programming\", \"GoLang\"]\n```", []string{"programming", "golang"}},
+		{"prose", `Sure! Here are the tags: ["programming", "golang"]. Hope it helps.`, []string{"programming", "golang"}},
+		{"object", `{"tags": ["programming", "golang"]}`, []string{"programming", "golang"}},
+		{"objects", `[{"tag": "programming"}, {"name": "golang"}]`, []string{"programming", "golang"}},
+		{"comma list", "Tags: programming, golang, programming", []string{"programming", "golang"}},
+		{"bullets", "- programming\n- system design\n", []string{"programming", "system design"}},
+	}
+	for _, c := range cases {
+		tags, err := parseTags(c.answer)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if !slices.Equal(tags, c.tags) {
+			t.Errorf("%s: unexpected tags %v", c.name, tags)
+		}
+	}
+}
+
+func TestParseTagsFailure(t *testing.T) {
+	for _, answer := range []string{"", `{"error": "no idea"}`, "[1, 2"} {
+		if tags, err := parseTags(answer); err == nil {
+			t.Errorf("Expected error for %q, got %v", answer, tags)
+		}
+	}
+}
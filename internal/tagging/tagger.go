@@ -0,0 +1,121 @@
+package tagging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+)
+
+const contentLimit = 4096
+
+// LLMTagger guesses tags with any llm provider.
+type LLMTagger struct {
+	cl       llm.ChatCompleter
+	taxonomy Taxonomy
+	prompt   string
+	text     extract.Options
+}
+
+// NewTagger creates tagger on top of completer, nil completer gives
+// a tagger which always fails, as there is no provider configured.
+// Guesses are limited to tags of the taxonomy, content HTML is converted
+// to text with the given options.
+func NewTagger(cl llm.ChatCompleter, taxonomy Taxonomy, text extract.Options) Tagger {
+	return LLMTagger{cl: cl, taxonomy: taxonomy, prompt: taxonomy.Prompt(), text: text}
+}
+
+func (tagger LLMTagger) GuessTags(ctx context.Context, title, content string, candidates []string) ([]string, error) {
+	if tagger.cl == nil {
+		return nil, errors.New("llm provider was not configured for tagging system")
+	}
+	content = extract.Text(content, tagger.text)
+	if content == "" {
+		return nil, errors.New("no content -> no tags")
+	}
+	messages := []llm.Message{
+		{
+			Role:    llm.RoleSystem,
+			Content: tagger.prompt,
+		},
+	}
+	if len(candidates) > 0 {
+		messages = append(messages, llm.Message{
+			Role:    llm.RoleSystem,
+			Content: fmt.Sprintf("Tags already used in the library, keep their spelling when they fit: %s.", strings.Join(candidates, ", ")),
+		})
+	}
+	messages = append(messages,
+		llm.Message{
+			Role:    llm.RoleUser,
+			Content: fmt.Sprintf("Title: %s", title),
+		},
+		llm.Message{
+			Role:    llm.RoleUser,
+			Content: fmt.Sprintf("Content of article: %s", llm.Truncate(content, contentLimit)),
+		},
+	)
+	resp, err := tagger.complete(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := parseTags(resp.Content)
+	if err != nil {
+		// ask once to fix the answer, models usually comply
+		resp, err = tagger.complete(ctx, append(messages,
+			llm.Message{Role: llm.RoleAssistant, Content: resp.Content},
+			llm.Message{Role: llm.RoleUser, Content: correctionPrompt},
+		))
+		if err != nil {
+			return nil, err
+		}
+		tags, err = parseTags(resp.Content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, resp.Content)
+		}
+	}
+	tags = tagger.filter(tags, candidates)
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no known tags in response: %s", resp.Content)
+	}
+
+	tags = append(tags[:min(maxTags, len(tags))], "autotag")
+	return tags, nil
+}
+
+func (tagger LLMTagger) complete(ctx context.Context, messages []llm.Message) (llm.Response, error) {
+	resp, err := tagger.cl.Complete(ctx, llm.Request{
+		Messages: messages,
+		Schema:   &llm.Schema{Name: "tags", Definition: tagsSchema},
+	})
+	if err != nil {
+		log.Printf("Tagging ChatCompletion error: %v\n", err)
+		return resp, err
+	}
+	log.Printf("Tagging ChatCompletion response from %s: %s\n", resp.Provider, resp.Content)
+	return resp, nil
+}
+
+// filter keeps taxonomy tags and tags resembling one of candidates,
+// the latter are replaced with the candidate spelling.
+func (tagger LLMTagger) filter(tags []string, candidates []string) []string {
+	var result []string
+	for _, tag := range tags {
+		if canonical, ok := tagger.taxonomy.Canonical(tag); ok {
+			tag = canonical
+		} else if label, ok := MatchTag(tag, candidates); ok {
+			tag = label
+		} else {
+			continue
+		}
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package tagging
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/llm"
+)
+
+type fakeCompleter struct {
+	responses []string
+	requests  []llm.Request
+}
+
+func (f *fakeCompleter) Complete(ctx context.Context, req llm.Request) (llm.Response, error) {
+	f.requests = append(f.requests, req)
+	content := f.responses[0]
+	if len(f.responses) > 1 {
+		f.responses = f.responses[1:]
+	}
+	return llm.Response{Content: content, Provider: "fake"}, nil
+}
+
+func TestLLMTaggerGuessTags(t *testing.T) {
+	cl := &fakeCompleter{responses: []string{`["programming", "golang"]`}}
+	tagger := NewTagger(cl, DefaultTaxonomy(), extract.Options{})
+
+	tags, err := tagger.GuessTags(context.Background(), "Title", "Content", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if !slices.Equal(tags, []string{"programming", "golang", "autotag"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+	if len(cl.requests) != 1 || cl.requests[0].Messages[0].Role != llm.RoleSystem {
+		t.Errorf("Unexpected requests %v", cl.requests)
+	}
+}
+
+func TestLLMTaggerWithoutProvider(t *testing.T) {
+	if _, err := NewTagger(nil, DefaultTaxonomy(), extract.Options{}).GuessTags(context.Background(), "Title", "Content", nil); err == nil {
+		t.Errorf("Expected error without provider")
+	}
+}
+
+func TestLLMTaggerFiltersByTaxonomy(t *testing.T) {
+	taxonomy := Taxonomy{
+		Categories: []string{"programming", "fun"},
+		Topics:     []string{"golang", "boardgame"},
+		Synonyms:   map[string][]string{"golang": {"go", "go lang"}},
+	}
+	cl := &fakeCompleter{responses: []string{`["Programming", "Go Lang", "obsidian", "golang"]`}}
+	tagger := NewTagger(cl, taxonomy, extract.Options{})
+
+	tags, err := tagger.GuessTags(context.Background(), "Title", "Content", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if !slices.Equal(tags, []string{"programming", "golang", "autotag"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+	prompt := cl.requests[0].Messages[0].Content
+	if !strings.Contains(prompt, "programming, fun") || !strings.Contains(prompt, "golang, boardgame") ||
+		!strings.Contains(prompt, `{"tags":["programming","golang","boardgame"]}`) {
+		t.Errorf("Prompt is not built from taxonomy: %s", prompt)
+	}
+}
+
+func TestLLMTaggerUnknownTags(t *testing.T) {
+	cl := &fakeCompleter{responses: []string{`["cooking"]`}}
+	if _, err := NewTagger(cl, DefaultTaxonomy(), extract.Options{}).GuessTags(context.Background(), "Title", "Content", nil); err == nil {
+		t.Errorf("Expected error when no tag is known")
+	}
+}
+
+func TestLLMTaggerPrefersCandidates(t *testing.T) {
+	cl := &fakeCompleter{responses: []string{`["programming", "Software-Architecture", "gamedev"]`}}
+	tagger := NewTagger(cl, DefaultTaxonomy(), extract.Options{})
+
+	tags, err := tagger.GuessTags(context.Background(), "Title", "Content", []string{"softwarearchitecture", "gamedevs"})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if !slices.Equal(tags, []string{"programming", "softwarearchitecture", "gamedevs", "autotag"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+	if !strings.Contains(cl.requests[0].Messages[1].Content, "softwarearchitecture, gamedevs") {
+		t.Errorf("Candidates are not offered to the model: %v", cl.requests[0].Messages)
+	}
+}
+
+func TestLLMTaggerReasksOnce(t *testing.T) {
+	cl := &fakeCompleter{responses: []string{`I think {"programming"`, `["programming", "golang"]`}}
+	tagger := NewTagger(cl, DefaultTaxonomy(), extract.Options{})
+
+	tags, err := tagger.GuessTags(context.Background(), "Title", "Content", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if !slices.Equal(tags, []string{"programming", "golang", "autotag"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+	if len(cl.requests) != 2 {
+		t.Fatalf("Expected corrective request, got %d requests", len(cl.requests))
+	}
+	last := cl.requests[1].Messages
+	if last[len(last)-2].Role != llm.RoleAssistant || last[len(last)-1].Content != correctionPrompt {
+		t.Errorf("Unexpected corrective request %v", last)
+	}
+	if cl.requests[0].Schema == nil {
+		t.Errorf("Schema was not requested")
+	}
+}
@@ -0,0 +1,132 @@
+package tagging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Taxonomy lists tags the model may choose from. Every guess contains one
+// category and one topic, Synonyms map alternative spellings to them.
+type Taxonomy struct {
+	Categories []string            `yaml:"categories" json:"categories"`
+	Topics     []string            `yaml:"topics" json:"topics"`
+	Synonyms   map[string][]string `yaml:"synonyms" json:"synonyms"`
+}
+
+func DefaultTaxonomy() Taxonomy {
+	return Taxonomy{
+		Categories: []string{
+			"programming", "software engineering", "infrastructure", "management",
+			"science", "business", "productivity", "fun",
+		},
+		Topics: []string{
+			"python", "golang", "django", "rust", "javascript", "bash", "testing",
+			"refactoring", "software architecture", "system design", "microservices",
+			"api", "event driven architecture", "monolith", "cloud", "docker", "nginx",
+			"k8s", "database", "postgresql", "shell", "monitoring", "devops",
+			"product management", "project management", "communication",
+			"documentation", "leadership", "agile", "estimates", "practices", "hiring",
+			"decision making", "system thinking", "history", "physics",
+			"quantum computing", "career", "startup", "finance", "time management",
+			"note taking", "writing", "obsidian", "learning", "videogame", "boardgame",
+			"book", "rant",
+		},
+		Synonyms: map[string][]string{
+			"golang":     {"go"},
+			"k8s":        {"kubernetes"},
+			"postgresql": {"postgres"},
+			"javascript": {"js"},
+		},
+	}
+}
+
+// LoadTaxonomy reads taxonomy from YAML or JSON file.
+func LoadTaxonomy(path string) (Taxonomy, error) {
+	var t Taxonomy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+	// JSON is valid YAML, so one decoder serves both formats
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return t, errors.Join(fmt.Errorf("wrong taxonomy format in %s", path), err)
+	}
+	return t, t.Validate()
+}
+
+func (t Taxonomy) Validate() error {
+	if len(t.Categories) == 0 {
+		return errors.New("taxonomy has no categories")
+	}
+	if len(t.Topics) == 0 {
+		return errors.New("taxonomy has no topics")
+	}
+	known := t.known()
+	seen := map[string]string{}
+	for canonical, synonyms := range t.Synonyms {
+		if !known[normalizeTag(canonical)] {
+			return fmt.Errorf("synonyms for unknown tag %q", canonical)
+		}
+		for _, synonym := range synonyms {
+			synonym = normalizeTag(synonym)
+			if other, ok := seen[synonym]; ok && other != canonical {
+				return fmt.Errorf("synonym %q is used for both %q and %q", synonym, other, canonical)
+			}
+			seen[synonym] = canonical
+		}
+	}
+	return nil
+}
+
+// Prompt builds system prompt for tagging from the taxonomy.
+func (t Taxonomy) Prompt() string {
+	return fmt.Sprintf(taggingPrompt,
+		strings.Join(t.Categories, ", "),
+		strings.Join(t.Topics, ", "),
+		exampleTags(t),
+	)
+}
+
+// Canonical returns taxonomy form of the tag, resolving synonyms.
+func (t Taxonomy) Canonical(tag string) (string, bool) {
+	tag = normalizeTag(tag)
+	for canonical, synonyms := range t.Synonyms {
+		for _, synonym := range synonyms {
+			if normalizeTag(synonym) == tag {
+				return normalizeTag(canonical), true
+			}
+		}
+	}
+	return tag, t.known()[tag]
+}
+
+func (t Taxonomy) known() map[string]bool {
+	known := map[string]bool{}
+	for _, tag := range t.Categories {
+		known[normalizeTag(tag)] = true
+	}
+	for _, tag := range t.Topics {
+		known[normalizeTag(tag)] = true
+	}
+	return known
+}
+
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+func exampleTags(t Taxonomy) string {
+	var example []string
+	if len(t.Categories) > 0 {
+		example = append(example, t.Categories[0])
+	}
+	example = append(example, t.Topics[:min(2, len(t.Topics))]...)
+	// same shape as tagsSchema, so the prompt agrees with structured output
+	data, _ := json.Marshal(map[string][]string{"tags": example})
+	return string(data)
+}
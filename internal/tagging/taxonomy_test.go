@@ -0,0 +1,49 @@
+package tagging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTaxonomy(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "taxonomy.yaml",
+			content: "categories: [cooking]\ntopics: [baking, grill]\nsynonyms:\n  grill: [bbq]\n",
+		},
+		{
+			name:    "taxonomy.json",
+			content: `{"categories": ["cooking"], "topics": ["baking"]}`,
+		},
+		{
+			name:    "no_topics.yaml",
+			content: "categories: [cooking]\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown_synonym.yaml",
+			content: "categories: [cooking]\ntopics: [baking]\nsynonyms:\n  grill: [bbq]\n",
+			wantErr: true,
+		},
+	}
+	dir := t.TempDir()
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		if err := os.WriteFile(path, []byte(c.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		taxonomy, err := LoadTaxonomy(path)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if err == nil && taxonomy.Categories[0] != "cooking" {
+			t.Errorf("%s: unexpected taxonomy %v", c.name, taxonomy)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package telegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const DefaultBaseURL = "https://api.telegra.ph"
+
+// Node is either a string or a NodeElement, as in Telegraph content format.
+type Node any
+
+type NodeElement struct {
+	Tag      string            `json:"tag"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Children []Node            `json:"children,omitempty"`
+}
+
+type Account struct {
+	ShortName   string `json:"short_name"`
+	AuthorName  string `json:"author_name"`
+	AccessToken string `json:"access_token"`
+}
+
+type Page struct {
+	Path  string `json:"path"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+type apiResponse struct {
+	Ok     bool            `json:"ok"`
+	Error  string          `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// Client is a minimal client of https://telegra.ph/api
+type Client struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewClient(client *http.Client, baseURL string) Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return Client{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (tc Client) CreateAccount(ctx context.Context, shortName, authorName string) (Account, error) {
+	var account Account
+	err := tc.call(ctx, "createAccount", url.Values{
+		"short_name":  {shortName},
+		"author_name": {authorName},
+	}, &account)
+	return account, err
+}
+
+func (tc Client) CreatePage(ctx context.Context, accessToken, title, authorName, authorURL string, content []Node) (Page, error) {
+	var page Page
+	data, err := json.Marshal(content)
+	if err != nil {
+		return page, err
+	}
+	err = tc.call(ctx, "createPage", url.Values{
+		"access_token": {accessToken},
+		"title":        {title},
+		"author_name":  {authorName},
+		"author_url":   {authorURL},
+		"content":      {string(data)},
+	}, &page)
+	return page, err
+}
+
+func (tc Client) call(ctx context.Context, method string, params url.Values, result any) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", tc.baseURL+"/"+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to telegraph %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegraph %s failed with status %d: %s", method, resp.StatusCode, resp.Status)
+	}
+
+	var response apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode telegraph %s response: %w", method, err)
+	}
+	if !response.Ok {
+		return errors.New("telegraph " + method + " failed: " + response.Error)
+	}
+	return json.Unmarshal(response.Result, result)
+}
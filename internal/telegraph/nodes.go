@@ -0,0 +1,131 @@
+package telegraph
+
+import (
+	"encoding/json"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MaxContentSize is the Telegraph limit for page content in bytes.
+const MaxContentSize = 64 * 1024
+
+// allowedTags are the elements supported by Telegraph, headings of other
+// levels are mapped to the closest one.
+var allowedTags = map[atom.Atom]string{
+	atom.A:          "a",
+	atom.Aside:      "aside",
+	atom.B:          "b",
+	atom.Strong:     "strong",
+	atom.Blockquote: "blockquote",
+	atom.Br:         "br",
+	atom.Code:       "code",
+	atom.Em:         "em",
+	atom.Figcaption: "figcaption",
+	atom.Figure:     "figure",
+	atom.Hr:         "hr",
+	atom.I:          "i",
+	atom.Iframe:     "iframe",
+	atom.Img:        "img",
+	atom.Li:         "li",
+	atom.Ol:         "ol",
+	atom.P:          "p",
+	atom.Pre:        "pre",
+	atom.S:          "s",
+	atom.U:          "u",
+	atom.Ul:         "ul",
+	atom.Video:      "video",
+	atom.H1:         "h3",
+	atom.H2:         "h3",
+	atom.H3:         "h3",
+	atom.H4:         "h4",
+	atom.H5:         "h4",
+	atom.H6:         "h4",
+}
+
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Svg:      true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Head:     true,
+}
+
+// HTMLToNodes converts wallabag HTML to Telegraph nodes. Unsupported
+// elements are unwrapped, so their text is kept without markup.
+func HTMLToNodes(content string) []Node {
+	root, err := xhtml.Parse(strings.NewReader(content))
+	if err != nil {
+		return []Node{content}
+	}
+	return convertChildren(root)
+}
+
+func convertChildren(n *xhtml.Node) []Node {
+	var nodes []Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, convert(c)...)
+	}
+	return nodes
+}
+
+func convert(n *xhtml.Node) []Node {
+	switch n.Type {
+	case xhtml.TextNode:
+		if strings.TrimSpace(n.Data) == "" && n.Parent != nil && n.Parent.DataAtom != atom.Pre {
+			return nil
+		}
+		return []Node{n.Data}
+	case xhtml.ElementNode:
+	default:
+		return convertChildren(n)
+	}
+	if droppedTags[n.DataAtom] {
+		return nil
+	}
+	tag, ok := allowedTags[n.DataAtom]
+	if !ok {
+		return convertChildren(n)
+	}
+	element := NodeElement{Tag: tag}
+	for _, a := range n.Attr {
+		if a.Key == "href" || a.Key == "src" {
+			if element.Attrs == nil {
+				element.Attrs = map[string]string{}
+			}
+			element.Attrs[a.Key] = a.Val
+		}
+	}
+	element.Children = convertChildren(n)
+	return []Node{element}
+}
+
+// Truncate drops trailing nodes until content fits into limit bytes,
+// appending a link to the original article when something was cut.
+func Truncate(nodes []Node, limit int, originalURL string) []Node {
+	data, _ := json.Marshal(nodes)
+	if len(data) <= limit {
+		return nodes
+	}
+	more := NodeElement{
+		Tag: "p",
+		Children: []Node{NodeElement{
+			Tag:      "a",
+			Attrs:    map[string]string{"href": originalURL},
+			Children: []Node{"Continue reading on the original site…"},
+		}},
+	}
+	moreData, _ := json.Marshal(more)
+	size := len(moreData) + 2
+	for i, node := range nodes {
+		nodeData, _ := json.Marshal(node)
+		if size+len(nodeData)+1 > limit {
+			return append(nodes[:i:i], more)
+		}
+		size += len(nodeData) + 1
+	}
+	return nodes
+}
@@ -0,0 +1,94 @@
+package telegraph
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+const (
+	pagesBucket   = "telegraph_pages"
+	accountBucket = "telegraph_account"
+	accountKey    = "default"
+
+	maxTitleLength = 256
+)
+
+// Publisher creates Telegraph pages for wallabag entries and caches their
+// URLs, so every entry is published only once.
+type Publisher struct {
+	client      Client
+	store       *storage.Store
+	authorName  string
+	accessToken string
+	// mx serializes account creation and publishing of the same entry
+	mx sync.Mutex
+}
+
+// NewPublisher uses accessToken when provided, otherwise a Telegraph account
+// is created on first publish and its token is kept in the store.
+func NewPublisher(client Client, store *storage.Store, accessToken, authorName string) *Publisher {
+	if authorName == "" {
+		authorName = "wallabot"
+	}
+	return &Publisher{
+		client:      client,
+		store:       store,
+		authorName:  authorName,
+		accessToken: accessToken,
+	}
+}
+
+// Publish returns the Telegraph URL of the entry, creating the page if needed.
+func (p *Publisher) Publish(ctx context.Context, entryID int, title, originalURL, content string) (string, error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	key := strconv.Itoa(entryID)
+	var page Page
+	if ok, err := p.store.Get(pagesBucket, key, &page); err == nil && ok {
+		return page.URL, nil
+	}
+
+	token, err := p.token(ctx)
+	if err != nil {
+		return "", err
+	}
+	if title == "" {
+		title = originalURL
+	}
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = string(runes[:maxTitleLength])
+	}
+	nodes := Truncate(HTMLToNodes(content), MaxContentSize, originalURL)
+	page, err = p.client.CreatePage(ctx, token, title, p.authorName, originalURL, nodes)
+	if err != nil {
+		return "", err
+	}
+	if err := p.store.Put(pagesBucket, key, page); err != nil {
+		return "", err
+	}
+	return page.URL, nil
+}
+
+func (p *Publisher) token(ctx context.Context) (string, error) {
+	if p.accessToken != "" {
+		return p.accessToken, nil
+	}
+	var account Account
+	if ok, err := p.store.Get(accountBucket, accountKey, &account); err == nil && ok && account.AccessToken != "" {
+		p.accessToken = account.AccessToken
+		return p.accessToken, nil
+	}
+	account, err := p.client.CreateAccount(ctx, p.authorName, p.authorName)
+	if err != nil {
+		return "", err
+	}
+	if err := p.store.Put(accountBucket, accountKey, account); err != nil {
+		return "", err
+	}
+	p.accessToken = account.AccessToken
+	return p.accessToken, nil
+}
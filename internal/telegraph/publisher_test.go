@@ -0,0 +1,79 @@
+package telegraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+)
+
+func TestPublisherPublish(t *testing.T) {
+	accounts := 0
+	pages := 0
+
+	// Start a local fake of Telegraph API
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var result any
+		switch req.URL.Path {
+		case "/createAccount":
+			accounts++
+			result = Account{ShortName: req.Form.Get("short_name"), AccessToken: "token"}
+		case "/createPage":
+			pages++
+			if req.Form.Get("access_token") != "token" {
+				t.Errorf("Wrong access token %s", req.Form.Get("access_token"))
+			}
+			var content []NodeElement
+			if err := json.Unmarshal([]byte(req.Form.Get("content")), &content); err != nil {
+				t.Errorf("Content is not valid nodes: %v", err)
+			}
+			if len(content) != 2 || content[0].Tag != "h3" || content[1].Tag != "p" {
+				t.Errorf("Unexpected content %s", req.Form.Get("content"))
+			}
+			result = Page{Path: "Title-01-01", URL: "https://telegra.ph/Title-01-01", Title: req.Form.Get("title")}
+		default:
+			t.Errorf("Incorrect path %s", req.URL.Path)
+			return
+		}
+		data, _ := json.Marshal(result)
+		fmt.Fprintf(rw, `{"ok":true,"result":%s}`, data)
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	store, _ := storage.NewStore("")
+	publisher := NewPublisher(NewClient(server.Client(), server.URL), store, "", "")
+
+	for i := 0; i < 2; i++ {
+		url, err := publisher.Publish(context.Background(), 1, "Title", "https://example.com", "<h1>Header</h1><div><p>Text <b>bold</b></p><script>x</script></div>")
+		if err != nil {
+			t.Fatalf("Unexpected error during %s", err)
+		}
+		if url != "https://telegra.ph/Title-01-01" {
+			t.Errorf("Unexpected url %s", url)
+		}
+	}
+	if accounts != 1 || pages != 1 {
+		t.Errorf("Expected account and page to be created once, got %d and %d", accounts, pages)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`{"ok":false,"error":"ACCESS_TOKEN_INVALID"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.Client(), server.URL).CreatePage(context.Background(), "bad", "Title", "", "", []Node{"text"})
+	if err == nil {
+		t.Errorf("Expected error from telegraph")
+	}
+}
@@ -0,0 +1,215 @@
+package urlnorm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const maxRedirects = 5
+
+// Rules describe how links are cleaned before saving.
+type Rules struct {
+	// StripParams are query parameters to drop, a trailing "*" matches a prefix
+	StripParams []string
+	// KeepParams exempt parameters of StripParams on some hosts, where they
+	// change the page, e.g. "ref" picks a branch on code hosts
+	KeepParams map[string][]string
+	// StripHostPrefixes turn mobile and AMP hosts into canonical ones
+	StripHostPrefixes []string
+	// Shorteners are hosts whose links are resolved by following redirects
+	Shorteners []string
+	// AMPHosts are sites serving AMP versions at ".../amp", the suffix is
+	// also dropped from links of Google AMP cache and AMP host prefixes
+	AMPHosts []string
+	// KeepFragment preserves "#..." part of the link, hash routes like
+	// "#/article/1" and "#!/article/1" are always kept
+	KeepFragment bool
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		StripParams: []string{
+			"utm_*", "fbclid", "gclid", "dclid", "yclid", "msclkid", "igshid",
+			"mc_cid", "mc_eid", "_hsenc", "_hsmi", "ref", "ref_src", "ref_url",
+			"amp",
+		},
+		KeepParams: map[string][]string{
+			"github.com": {"ref"},
+			"gitlab.com": {"ref"},
+		},
+		StripHostPrefixes: []string{"m.", "mobile.", "amp."},
+		Shorteners: []string{
+			"t.co", "bit.ly", "goo.gl", "tinyurl.com", "ow.ly", "buff.ly",
+			"lnkd.in", "dlvr.it", "is.gd",
+		},
+	}
+}
+
+type Normalizer struct {
+	rules  Rules
+	client *http.Client
+}
+
+// NewNormalizer creates normalizer, client is used only to resolve
+// shortened links and may be nil to disable resolving.
+func NewNormalizer(rules Rules, client *http.Client) *Normalizer {
+	if client != nil {
+		noFollow := *client
+		noFollow.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		client = &noFollow
+	}
+	return &Normalizer{rules: rules, client: client}
+}
+
+// Normalize returns canonical form of the link. Links that can't be parsed
+// are returned untouched, so normalisation never prevents saving.
+func (n *Normalizer) Normalize(ctx context.Context, raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u = n.resolve(ctx, u)
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		u.Host = u.Hostname()
+	}
+	amp := false
+	if target, ok := googleAMPTarget(u); ok {
+		u = target
+		amp = true
+	}
+	for _, prefix := range n.rules.StripHostPrefixes {
+		if host, ok := strings.CutPrefix(u.Host, prefix); ok && strings.Contains(host, ".") {
+			u.Host = host
+			amp = amp || strings.HasPrefix(prefix, "amp")
+			break
+		}
+	}
+	// elsewhere "/amp" may be a real part of the path, e.g. a repository name
+	if amp || n.isAMPHost(u) {
+		u.Path = stripAMPPath(u.Path)
+	}
+	u.RawQuery = n.cleanQuery(u, u.RawQuery)
+	if !n.rules.KeepFragment && !isHashRoute(u.Fragment) {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+	return u.String()
+}
+
+// cleanQuery drops stripped parameters, the rest keep their order and form,
+// as some sites depend on them.
+func (n *Normalizer) cleanQuery(u *url.URL, rawQuery string) string {
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if pair == "" || n.stripped(u, key) {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
+
+func (n *Normalizer) stripped(u *url.URL, key string) bool {
+	key = strings.ToLower(key)
+	if slices.Contains(n.rules.KeepParams[strings.TrimPrefix(u.Hostname(), "www.")], key) {
+		return false
+	}
+	for _, rule := range n.rules.StripParams {
+		if prefix, ok := strings.CutSuffix(rule, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve follows redirects of known shorteners, on any error the link
+// stays as it is.
+func (n *Normalizer) resolve(ctx context.Context, u *url.URL) *url.URL {
+	if n.client == nil {
+		return u
+	}
+	for i := 0; i < maxRedirects && n.isShortener(u); i++ {
+		resp, err := n.request(ctx, "HEAD", u)
+		if err != nil {
+			return u
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusMethodNotAllowed {
+			resp, err = n.request(ctx, "GET", u)
+			if err != nil {
+				return u
+			}
+			resp.Body.Close()
+		}
+		location, err := resp.Location()
+		if err != nil {
+			return u
+		}
+		u = location
+	}
+	return u
+}
+
+func (n *Normalizer) request(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return n.client.Do(req)
+}
+
+func (n *Normalizer) isShortener(u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	return slices.Contains(n.rules.Shorteners, host) || slices.Contains(n.rules.Shorteners, strings.TrimPrefix(u.Hostname(), "www."))
+}
+
+func (n *Normalizer) isAMPHost(u *url.URL) bool {
+	return slices.Contains(n.rules.AMPHosts, strings.TrimPrefix(u.Hostname(), "www."))
+}
+
+// isHashRoute reports whether the fragment is a route of a single page
+// application rather than an anchor, dropping it leads to the site root.
+func isHashRoute(fragment string) bool {
+	return strings.HasPrefix(fragment, "/") || strings.HasPrefix(fragment, "!")
+}
+
+// googleAMPTarget unwraps links like https://www.google.com/amp/s/example.com/page
+func googleAMPTarget(u *url.URL) (*url.URL, bool) {
+	if !strings.HasPrefix(u.Hostname(), "www.google.") && !strings.HasPrefix(u.Hostname(), "google.") {
+		return nil, false
+	}
+	rest, ok := strings.CutPrefix(u.Path, "/amp/s/")
+	if !ok {
+		return nil, false
+	}
+	target, err := url.Parse("https://" + rest)
+	if err != nil || target.Host == "" {
+		return nil, false
+	}
+	target.RawQuery = u.RawQuery
+	return target, true
+}
+
+func stripAMPPath(path string) string {
+	trimmed := strings.TrimSuffix(path, "/")
+	if strings.HasSuffix(trimmed, "/amp") {
+		return strings.TrimSuffix(trimmed, "amp")
+	}
+	return path
+}
@@ -0,0 +1,114 @@
+package urlnorm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	normalizer := NewNormalizer(DefaultRules(), nil)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"untouched", "https://example.com/post", "https://example.com/post"},
+		{"utm params", "https://example.com/post?utm_source=tg&utm_medium=social&id=5", "https://example.com/post?id=5"},
+		{"click ids", "https://example.com/post?fbclid=abc&gclid=def", "https://example.com/post"},
+		{"ref", "https://example.com/post?ref=newsletter", "https://example.com/post"},
+		{"ref on code host", "https://github.com/owner/repo/blob/main/README.md?ref=v1", "https://github.com/owner/repo/blob/main/README.md?ref=v1"},
+		{"twitter ref", "https://example.com/post?ref_src=twsrc", "https://example.com/post"},
+		{"uppercase param", "https://example.com/post?UTM_Source=x", "https://example.com/post"},
+		{"params order", "https://example.com/?b=2&utm_source=x&a=1", "https://example.com/?b=2&a=1"},
+		{"bare param", "https://example.com/post?print&fbclid=abc", "https://example.com/post?print"},
+		{"escaped param", "https://example.com/?q=a%20b&utm%5Fsource=x", "https://example.com/?q=a%20b"},
+		{"mobile host", "https://m.example.com/post", "https://example.com/post"},
+		{"mobile subdomain", "https://mobile.twitter.com/user/status/1", "https://twitter.com/user/status/1"},
+		{"amp host", "https://amp.example.com/post", "https://example.com/post"},
+		{"amp path", "https://example.com/post/amp/", "https://example.com/post/amp/"},
+		{"amp repository", "https://github.com/ampproject/amp", "https://github.com/ampproject/amp"},
+		{"amp host path", "https://amp.example.com/post/amp", "https://example.com/post/"},
+		{"google amp", "https://www.google.com/amp/s/example.com/post", "https://example.com/post"},
+		{"google amp path", "https://www.google.com/amp/s/example.com/post/amp", "https://example.com/post/"},
+		{"host case and port", "HTTPS://Example.COM:443/Post", "https://example.com/Post"},
+		{"fragment", "https://example.com/post#comments", "https://example.com/post"},
+		{"hash route", "https://example.com/#/article/1", "https://example.com/#/article/1"},
+		{"hashbang route", "https://example.com/#!/article/1", "https://example.com/#!/article/1"},
+		{"bare m host", "https://m.com/post", "https://m.com/post"},
+		{"invalid", "not a url", "not a url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizer.Normalize(context.Background(), tt.input); got != tt.expected {
+				t.Errorf("Normalize(%q) = %q, expected %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeCustomRules(t *testing.T) {
+	rules := Rules{
+		StripParams:  []string{"session"},
+		AMPHosts:     []string{"news.example.com"},
+		KeepFragment: true,
+	}
+	normalizer := NewNormalizer(rules, nil)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://m.example.com/post?session=1&utm_source=x#top", "https://m.example.com/post?utm_source=x#top"},
+		{"https://www.news.example.com/post/amp", "https://www.news.example.com/post/"},
+		{"https://example.com/post/amp", "https://example.com/post/amp"},
+	}
+	for _, tt := range tests {
+		if got := normalizer.Normalize(context.Background(), tt.input); got != tt.expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNormalizeResolvesShorteners(t *testing.T) {
+	// Start a local shortener which redirects twice
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/abc":
+			http.Redirect(rw, req, "/def", http.StatusMovedPermanently)
+		case "/def":
+			http.Redirect(rw, req, "https://example.com/post?utm_source=twitter", http.StatusFound)
+		case "/head-not-allowed":
+			if req.Method == http.MethodHead {
+				rw.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			http.Redirect(rw, req, "https://example.com/other", http.StatusFound)
+		default:
+			http.NotFound(rw, req)
+		}
+	}))
+	defer server.Close()
+
+	shortener, _ := url.Parse(server.URL)
+	rules := DefaultRules()
+	rules.Shorteners = append(rules.Shorteners, shortener.Host)
+	normalizer := NewNormalizer(rules, server.Client())
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{server.URL + "/abc", "https://example.com/post"},
+		{server.URL + "/head-not-allowed", "https://example.com/other"},
+		{server.URL + "/missing", server.URL + "/missing"},
+	}
+	for _, tt := range tests {
+		if got := normalizer.Normalize(context.Background(), tt.input); got != tt.expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
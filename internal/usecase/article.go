@@ -1,75 +1,106 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"log"
+	"strings"
 	"sync"
 
 	"math/rand/v2"
 	"time"
 
+	"github.com/vanadium23/wallabag-telegram-bot/internal/embeddings"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/rules"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/summarization"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/tagging"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/urlnorm"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
 )
 
 const mxPool int = 64
 
 type WallabotArticleUseCase struct {
-	wc     wallabag.WallabagClient
-	tagger tagging.Tagger
-	mxs    [mxPool]sync.Mutex
+	wc             wallabag.WallabagAPI
+	tagger         tagging.Tagger
+	normalizer     *urlnorm.Normalizer
+	rules          *rules.Engine
+	buckets        ReadingTimeBuckets
+	tags           *TagCache
+	summarizer     summarization.Summarizer
+	summaryStorage string
+	index          *embeddings.Index
+	syncMx         sync.Mutex
+	mxs            [mxPool]sync.Mutex
 }
 
-func NewWallabotArticleUseCase(wc wallabag.WallabagClient, tagger tagging.Tagger) *WallabotArticleUseCase {
+func NewWallabotArticleUseCase(
+	wc wallabag.WallabagAPI,
+	tagger tagging.Tagger,
+	normalizer *urlnorm.Normalizer,
+	rulesEngine *rules.Engine,
+	buckets ReadingTimeBuckets,
+	tags *TagCache,
+	summarizer summarization.Summarizer,
+	summaryStorage string,
+	index *embeddings.Index,
+) *WallabotArticleUseCase {
 	return &WallabotArticleUseCase{
-		wc:     wc,
-		tagger: tagger,
-		mxs:    [mxPool]sync.Mutex{},
+		wc:             wc,
+		tagger:         tagger,
+		normalizer:     normalizer,
+		rules:          rulesEngine,
+		buckets:        buckets,
+		tags:           tags,
+		summarizer:     summarizer,
+		summaryStorage: summaryStorage,
+		index:          index,
+		mxs:            [mxPool]sync.Mutex{},
 	}
 }
 
-func (wau *WallabotArticleUseCase) MarkRead(entryID int) (WallabotArticle, error) {
+func (wau *WallabotArticleUseCase) MarkRead(ctx context.Context, entryID int) (WallabotArticle, error) {
 	wau.mxs[entryID%mxPool].Lock()
 	defer wau.mxs[entryID%mxPool].Unlock()
 
-	entry, err := wau.wc.UpdateArticle(entryID, 1)
+	entry, err := wau.wc.UpdateArticle(ctx, entryID, 1)
 	if err != nil {
 		return WallabotArticle{}, err
 	}
 	return NewWallabotArticle(entry), nil
 }
 
-func (wau *WallabotArticleUseCase) MarkUnread(entryID int) (WallabotArticle, error) {
+func (wau *WallabotArticleUseCase) MarkUnread(ctx context.Context, entryID int) (WallabotArticle, error) {
 	wau.mxs[entryID%mxPool].Lock()
 	defer wau.mxs[entryID%mxPool].Unlock()
 
-	entry, err := wau.wc.UpdateArticle(entryID, 0)
+	entry, err := wau.wc.UpdateArticle(ctx, entryID, 0)
 	if err != nil {
 		return WallabotArticle{}, err
 	}
 	return NewWallabotArticle(entry), nil
 }
 
-func (wau *WallabotArticleUseCase) MarkScrolled(entryID int) (WallabotArticle, error) {
+func (wau *WallabotArticleUseCase) MarkScrolled(ctx context.Context, entryID int) (WallabotArticle, error) {
 	wau.mxs[entryID%mxPool].Lock()
 	defer wau.mxs[entryID%mxPool].Unlock()
 
-	_, err := wau.wc.AddTagsToArticle(entryID, []string{"scrolled"})
+	_, err := wau.wc.AddTagsToArticle(ctx, entryID, []string{"scrolled"})
 
 	if err != nil {
 		return WallabotArticle{}, err
 	}
 
-	entry, err := wau.wc.UpdateArticle(entryID, 1)
+	entry, err := wau.wc.UpdateArticle(ctx, entryID, 1)
 	if err != nil {
 		return WallabotArticle{}, err
 	}
 	return NewWallabotArticle(entry), nil
 }
 
-// func (wau *WallabotArticleUseCase) DeleteScrolled(entryID int) (WallabotArticle, error) {}
+// func (wau *WallabotArticleUseCase) DeleteScrolled(ctx context.Context, entryID int) (WallabotArticle, error) {}
 
-func (wau *WallabotArticleUseCase) AddRating(entryID int, rating string) (WallabotArticle, error) {
+func (wau *WallabotArticleUseCase) AddRating(ctx context.Context, entryID int, rating string) (WallabotArticle, error) {
 	_, ok := RatingFromString(rating)
 	if !ok {
 		return WallabotArticle{}, errors.New("rating invalid")
@@ -78,41 +109,122 @@ func (wau *WallabotArticleUseCase) AddRating(entryID int, rating string) (Wallab
 	wau.mxs[entryID%mxPool].Lock()
 	defer wau.mxs[entryID%mxPool].Unlock()
 
-	_, err := wau.wc.AddTagsToArticle(entryID, []string{rating})
+	_, err := wau.wc.AddTagsToArticle(ctx, entryID, []string{rating})
 
 	if err != nil {
 		return WallabotArticle{}, err
 	}
 
-	entry, err := wau.wc.UpdateArticle(entryID, 1)
+	entry, err := wau.wc.UpdateArticle(ctx, entryID, 1)
 	if err != nil {
 		return WallabotArticle{}, err
 	}
 	return NewWallabotArticle(entry), nil
 }
 
-// func (wau *WallabotArticleUseCase) DeleteRating(entryID int) (WallabotArticle, error)   {}
+// func (wau *WallabotArticleUseCase) DeleteRating(ctx context.Context, entryID int) (WallabotArticle, error)   {}
 
-func (wau *WallabotArticleUseCase) SaveForLater(url string) (WallabotArticle, error) {
-	entry, err := wau.wc.CreateArticle(url)
+func (wau *WallabotArticleUseCase) SaveForLater(ctx context.Context, url string) (WallabotArticle, error) {
+	url = wau.normalizer.Normalize(ctx, url)
+	existingID, err := wau.wc.EntryExists(ctx, url)
+	if err != nil {
+		log.Printf("error on checking duplicates: %v\n", err)
+	}
+	if existingID != 0 {
+		entry, err := wau.wc.FetchArticle(ctx, existingID)
+		if err != nil {
+			return WallabotArticle{}, err
+		}
+		article := NewWallabotArticle(entry)
+		article.AlreadySaved = true
+		return article, nil
+	}
+
+	entry, err := wau.wc.CreateArticle(ctx, url)
 	if err != nil {
 		return WallabotArticle{}, err
 	}
-	tags, err := wau.tagger.GuessTags(entry.Title, entry.Content)
+	// deterministic rules go first, LLM tags are added on top of them
+	ruled := wau.rules.Apply(rules.Entry{
+		URL:         entry.Url,
+		Title:       entry.Title,
+		ReadingTime: entry.ReadingTime,
+	})
+	if len(ruled.Matched) > 0 {
+		log.Printf("entry %d matched rules: %s\n", entry.ID, strings.Join(ruled.Matched, ", "))
+	}
+	guessed, err := wau.tagger.GuessTags(ctx, entry.Title, entryContent(entry), wau.tags.Candidates(ctx))
 	if err != nil {
 		log.Printf("error on tagging: %v\n", err)
 	}
-	if tags != nil {
-		entry, err = wau.wc.AddTagsToArticle(entry.ID, tags)
+	guessed = wau.tags.Match(ctx, guessed)
+	var bucket []string
+	if tag := wau.buckets.Tag(entry.ReadingTime); tag != "" {
+		bucket = []string{tag}
+	}
+	tags := mergeTags(ruled.Tags, bucket, guessed)
+	if len(tags) > 0 {
+		entry, err = wau.wc.AddTagsToArticle(ctx, entry.ID, tags)
+		if err != nil {
+			return WallabotArticle{}, err
+		}
+		wau.tags.Add(tags)
+	}
+	if ruled.Star {
+		entry, err = wau.wc.StarArticle(ctx, entry.ID, 1)
+		if err != nil {
+			return WallabotArticle{}, err
+		}
+	}
+	if ruled.Archive {
+		entry, err = wau.wc.UpdateArticle(ctx, entry.ID, 1)
 		if err != nil {
 			return WallabotArticle{}, err
 		}
 	}
-	return NewWallabotArticle(entry), err
+	return NewWallabotArticle(entry), nil
+}
+
+func (wau *WallabotArticleUseCase) ListRules() []rules.Rule {
+	return wau.rules.Rules()
+}
+
+// TestRules evaluates rules against a link, using title and reading time of
+// the saved entry when the link is already in wallabag.
+func (wau *WallabotArticleUseCase) TestRules(ctx context.Context, url string) (rules.Result, error) {
+	url = wau.normalizer.Normalize(ctx, url)
+	entry := rules.Entry{URL: url}
+	existingID, err := wau.wc.EntryExists(ctx, url)
+	if err != nil {
+		return rules.Result{}, err
+	}
+	if existingID != 0 {
+		saved, err := wau.wc.FetchArticle(ctx, existingID)
+		if err != nil {
+			return rules.Result{}, err
+		}
+		entry.Title = saved.Title
+		entry.ReadingTime = saved.ReadingTime
+	}
+	return wau.rules.Apply(entry), nil
+}
+
+func mergeTags(groups ...[]string) []string {
+	var merged []string
+	seen := map[string]bool{}
+	for _, group := range groups {
+		for _, tag := range group {
+			if !seen[tag] {
+				seen[tag] = true
+				merged = append(merged, tag)
+			}
+		}
+	}
+	return merged
 }
 
-func (wau *WallabotArticleUseCase) FindRandom(count int) ([]WallabotArticle, error) {
-	entries, err := wau.wc.FetchArticles(1, 100, 0, nil)
+func (wau *WallabotArticleUseCase) FindRandom(ctx context.Context, count int) ([]WallabotArticle, error) {
+	entries, err := wau.wc.FetchArticles(ctx, 1, 100, 0, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -124,8 +236,8 @@ func (wau *WallabotArticleUseCase) FindRandom(count int) ([]WallabotArticle, err
 	return articles, nil
 }
 
-func (wau *WallabotArticleUseCase) FindRecent(count int) ([]WallabotArticle, error) {
-	entries, err := wau.wc.FetchArticles(1, count, 0, nil)
+func (wau *WallabotArticleUseCase) FindRecent(ctx context.Context, count int) ([]WallabotArticle, error) {
+	entries, err := wau.wc.FetchArticles(ctx, 1, count, 0, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -137,8 +249,8 @@ func (wau *WallabotArticleUseCase) FindRecent(count int) ([]WallabotArticle, err
 	return articles, nil
 }
 
-func (wau *WallabotArticleUseCase) FindShort(count int) ([]WallabotArticle, error) {
-	entries, err := wau.wc.FetchArticles(1, 100, 0, []string{"short"})
+func (wau *WallabotArticleUseCase) FindShort(ctx context.Context, count int) ([]WallabotArticle, error) {
+	entries, err := wau.wc.FetchArticles(ctx, 1, 100, 0, []string{"short"})
 	if err != nil {
 		return nil, err
 	}
@@ -150,22 +262,22 @@ func (wau *WallabotArticleUseCase) FindShort(count int) ([]WallabotArticle, erro
 	return articles, nil
 }
 
-func (wau *WallabotArticleUseCase) FindByID(entryID int) (WallabotArticle, error) {
+func (wau *WallabotArticleUseCase) FindByID(ctx context.Context, entryID int) (WallabotArticle, error) {
 	wau.mxs[entryID%mxPool].Lock()
 	defer wau.mxs[entryID%mxPool].Unlock()
 
-	entry, err := wau.wc.FetchArticle(entryID)
+	entry, err := wau.wc.FetchArticle(ctx, entryID)
 	if err != nil {
 		return WallabotArticle{}, err
 	}
 	return NewWallabotArticle(entry), nil
 }
 
-func (wau *WallabotArticleUseCase) GetStats() (WallabagStats, error) {
+func (wau *WallabotArticleUseCase) GetStats(ctx context.Context) (WallabagStats, error) {
 	var stats WallabagStats
 
 	// Get total unread articles (archive=0 means unread)
-	unreadEntries, err := wau.wc.FetchArticlesWithSince(1, 1000, 0, 0, nil, "metadata")
+	unreadEntries, err := wau.wc.FetchArticlesWithSince(ctx, 1, 1000, 0, 0, nil, "metadata")
 	if err != nil {
 		return stats, err
 	}
@@ -181,7 +293,7 @@ func (wau *WallabotArticleUseCase) GetStats() (WallabagStats, error) {
 	sevenDaysAgoUnix := sevenDaysAgo.Unix()
 
 	// Get archived articles from the last 7 days using the 'since' parameter
-	recentArchivedEntries, err := wau.wc.FetchArticlesWithSince(1, 1000, 1, sevenDaysAgoUnix, nil, "metadata")
+	recentArchivedEntries, err := wau.wc.FetchArticlesWithSince(ctx, 1, 1000, 1, sevenDaysAgoUnix, nil, "metadata")
 	if err != nil {
 		return stats, err
 	}
@@ -205,13 +317,13 @@ func (wau *WallabotArticleUseCase) GetStats() (WallabagStats, error) {
 
 	// Get articles added in the last 7 days (both archived and unarchived)
 	// First get unarchived articles created since 7 days ago
-	recentUnreadEntries, err := wau.wc.FetchArticlesWithSince(1, 1000, 0, sevenDaysAgoUnix, nil, "metadata")
+	recentUnreadEntries, err := wau.wc.FetchArticlesWithSince(ctx, 1, 1000, 0, sevenDaysAgoUnix, nil, "metadata")
 	if err != nil {
 		return stats, err
 	}
 
 	// Then get archived articles created since 7 days ago (different from the archived articles query above)
-	recentAllArchivedEntries, err := wau.wc.FetchArticlesWithSince(1, 1000, 1, sevenDaysAgoUnix, nil, "metadata")
+	recentAllArchivedEntries, err := wau.wc.FetchArticlesWithSince(ctx, 1, 1000, 1, sevenDaysAgoUnix, nil, "metadata")
 	if err != nil {
 		return stats, err
 	}
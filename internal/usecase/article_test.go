@@ -0,0 +1,327 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/rules"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/summarization"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/urlnorm"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag/wallabagtest"
+)
+
+type fakeTagger struct {
+	tags       []string
+	candidates []string
+}
+
+func (f *fakeTagger) GuessTags(ctx context.Context, title, content string, candidates []string) ([]string, error) {
+	f.candidates = candidates
+	return f.tags, nil
+}
+
+type fakeSummarizer struct {
+	calls int
+}
+
+func (f *fakeSummarizer) Summarize(ctx context.Context, title, content string, opts summarization.Options) (string, error) {
+	f.calls++
+	return "Summary of " + title, nil
+}
+
+func newTestUseCase(t *testing.T, wc wallabag.WallabagAPI, tagger *fakeTagger, rulesList []rules.Rule) *WallabotArticleUseCase {
+	t.Helper()
+	engine, err := rules.NewEngine(rulesList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewWallabotArticleUseCase(
+		wc,
+		tagger,
+		urlnorm.NewNormalizer(urlnorm.DefaultRules(), nil),
+		engine,
+		DefaultReadingTimeBuckets(),
+		NewTagCache(wc, time.Hour, 10, []string{"wallabot"}),
+		&fakeSummarizer{},
+		SummaryStorageAnnotation,
+		nil,
+	)
+}
+
+func labels(entry wallabag.WallabagEntry) []string {
+	var labels []string
+	for _, tag := range entry.Tags {
+		labels = append(labels, tag.Label)
+	}
+	return labels
+}
+
+func TestSaveForLater(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	fake.Add(wallabag.WallabagEntry{Url: "https://go.dev/blog/"}, "golang")
+	tagger := &fakeTagger{tags: []string{"Golang", "concurrency"}}
+	wau := newTestUseCase(t, fake, tagger, []rules.Rule{
+		{Name: "docs", Domain: "go.dev", Tags: []string{"docs"}, Archive: true},
+	})
+
+	article, err := wau.SaveForLater(ctx, "https://go.dev/doc/effective_go?utm_source=feed")
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if article.AlreadySaved || !article.IsRead || article.Url != "https://go.dev/doc/effective_go" {
+		t.Errorf("Unexpected article %+v", article)
+	}
+	entry, _ := fake.Entry(article.ID)
+	// guessed tags are matched against labels of the library
+	if got := labels(entry); !slices.Equal(got, []string{"docs", "golang", "concurrency"}) {
+		t.Errorf("Unexpected tags %v", got)
+	}
+	if !slices.Equal(tagger.candidates, []string{"golang"}) {
+		t.Errorf("Unexpected candidates %v", tagger.candidates)
+	}
+
+	again, err := wau.SaveForLater(ctx, "https://go.dev/doc/effective_go")
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if !again.AlreadySaved || again.ID != article.ID {
+		t.Errorf("Duplicate should be reported, got %+v", again)
+	}
+}
+
+func TestMarkScrolledAndRating(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	entry := fake.Add(wallabag.WallabagEntry{Url: "https://example.com/a"})
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+
+	article, err := wau.MarkScrolled(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if !article.IsRead || !article.Scrolled {
+		t.Errorf("Unexpected article %+v", article)
+	}
+	article, err = wau.AddRating(ctx, entry.ID, "great")
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if !article.HasRating || article.PublicTags() != "scrolled, great" {
+		t.Errorf("Unexpected article %+v", article)
+	}
+	if _, err := wau.AddRating(ctx, entry.ID, "meh"); err == nil {
+		t.Error("Invalid rating should be rejected")
+	}
+	if _, err := wau.MarkRead(ctx, 404); !errors.Is(err, wallabag.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	now := time.Now()
+	at := func(d time.Duration) *wallabag.WallabagTime {
+		return &wallabag.WallabagTime{Time: now.Add(-d)}
+	}
+	fake.Add(wallabag.WallabagEntry{Url: "https://example.com/old", CreatedAt: at(240 * time.Hour)})
+	fake.Add(wallabag.WallabagEntry{Url: "https://example.com/new"})
+	read := fake.Add(wallabag.WallabagEntry{Url: "https://example.com/read", CreatedAt: at(72 * time.Hour)})
+	fake.Add(wallabag.WallabagEntry{Url: "https://example.com/long-ago", CreatedAt: at(720 * time.Hour), IsArchived: 1})
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+	if _, err := wau.MarkRead(ctx, read.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := wau.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	expected := WallabagStats{TotalUnread: 2, ArchivedToday: 1, ArchivedLast7Days: 1, AddedLast7Days: 2}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestBackfillReadingTime(t *testing.T) {
+	fake := wallabagtest.New()
+	for i := 0; i < backfillPageSize+5; i++ {
+		fake.Add(wallabag.WallabagEntry{ReadingTime: 3})
+	}
+	tagged := fake.Add(wallabag.WallabagEntry{ReadingTime: 20}, "medium")
+	unknown := fake.Add(wallabag.WallabagEntry{IsArchived: 1})
+	long := fake.Add(wallabag.WallabagEntry{ReadingTime: 20, IsArchived: 1})
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+
+	updated, err := wau.BackfillReadingTime(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if updated != backfillPageSize+6 {
+		t.Errorf("Unexpected number of updated entries %d", updated)
+	}
+	for id, expected := range map[int][]string{1: {"short"}, tagged.ID: {"medium"}, unknown.ID: nil, long.ID: {"long"}} {
+		entry, _ := fake.Entry(id)
+		if got := labels(entry); !slices.Equal(got, expected) {
+			t.Errorf("Entry %d: expected %v, got %v", id, expected, got)
+		}
+	}
+}
+
+// failingTags rejects tagging of one entry.
+type failingTags struct {
+	*wallabagtest.Fake
+	entryID int
+}
+
+func (f failingTags) AddTagsToArticle(ctx context.Context, entryID int, tags []string) (wallabag.WallabagEntry, error) {
+	if entryID == f.entryID {
+		return wallabag.WallabagEntry{}, wallabag.ErrServerError
+	}
+	return f.Fake.AddTagsToArticle(ctx, entryID, tags)
+}
+
+func TestBackfillReadingTimeSkipsFailures(t *testing.T) {
+	fake := wallabagtest.New()
+	// a full last page is followed by 404 from wallabag
+	for i := 0; i < backfillPageSize; i++ {
+		fake.Add(wallabag.WallabagEntry{ReadingTime: 10})
+	}
+	wau := newTestUseCase(t, failingTags{Fake: fake, entryID: 7}, &fakeTagger{}, nil)
+
+	updated, err := wau.BackfillReadingTime(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if updated != backfillPageSize-1 {
+		t.Errorf("Unexpected number of updated entries %d", updated)
+	}
+	if entry, _ := fake.Entry(8); !slices.Equal(labels(entry), []string{"medium"}) {
+		t.Errorf("Entries after the failed one should be tagged, got %v", labels(entry))
+	}
+}
+
+func TestRelated(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	entry := fake.Add(wallabag.WallabagEntry{}, "golang", "databases", "short", "autotag")
+	both := fake.Add(wallabag.WallabagEntry{IsArchived: 1}, "golang", "databases")
+	one := fake.Add(wallabag.WallabagEntry{}, "databases")
+	fake.Add(wallabag.WallabagEntry{}, "short", "autotag")
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+
+	articles, total, err := wau.Related(ctx, entry.ID, 0, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if total != 2 || len(articles) != 2 || articles[0].ID != both.ID || articles[1].ID != one.ID {
+		t.Errorf("Unexpected related entries %v of %d", articles, total)
+	}
+	articles, _, err = wau.Related(ctx, entry.ID, 1, 5)
+	if err != nil || len(articles) != 1 || articles[0].ID != one.ID {
+		t.Errorf("Unexpected second page %v, %v", articles, err)
+	}
+}
+
+func TestRelatedSkipsDefaultTags(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	entry := fake.Add(wallabag.WallabagEntry{}, "golang", "wallabot")
+	fake.Add(wallabag.WallabagEntry{}, "cooking", "wallabot")
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+
+	articles, total, err := wau.Related(ctx, entry.ID, 0, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if total != 0 || len(articles) != 0 {
+		t.Errorf("Entries sharing only a default tag should not be related, got %v of %d", articles, total)
+	}
+}
+
+func TestSummarizeStoresAnnotation(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	entry := fake.Add(wallabag.WallabagEntry{Title: "Go", Content: "<p>Go is fun</p>"})
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+	summarizer := wau.summarizer.(*fakeSummarizer)
+
+	for i := 0; i < 2; i++ {
+		summary, err := wau.Summarize(ctx, entry.ID, summarization.DefaultOptions())
+		if err != nil {
+			t.Fatalf("Unexpected error during %s", err)
+		}
+		if summary != "Summary of Go" {
+			t.Errorf("Unexpected summary %q", summary)
+		}
+	}
+	if summarizer.calls != 1 {
+		t.Errorf("Stored summary should be reused, summarizer called %d times", summarizer.calls)
+	}
+	annotations, _ := fake.FetchAnnotations(ctx, entry.ID)
+	if len(annotations) != 1 || !strings.HasPrefix(annotations[0].Text, "🤖 Summary") {
+		t.Errorf("Unexpected annotations %v", annotations)
+	}
+}
+
+func TestSummaryInContentIsNotArticle(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	entry := fake.Add(wallabag.WallabagEntry{Title: "Go", Content: "<p>Go is fun</p>"})
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+	wau.summaryStorage = SummaryStorageContent
+
+	if _, err := wau.Summarize(ctx, entry.ID, summarization.DefaultOptions()); err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if stored, _ := fake.Entry(entry.ID); !strings.Contains(stored.Content, "Summary of Go") {
+		t.Fatalf("Summary is not stored in content: %s", stored.Content)
+	}
+	article, err := wau.FindByID(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if article.Content != "<p>Go is fun</p>" {
+		t.Errorf("Unexpected article content %q", article.Content)
+	}
+}
+
+func TestExportByTag(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	fake.Add(wallabag.WallabagEntry{Title: "First", Url: "https://example.com/1"}, "weekend")
+	fake.Add(wallabag.WallabagEntry{Title: "Second", Url: "https://example.com/2"}, "weekend")
+	fake.Add(wallabag.WallabagEntry{Title: "Read", IsArchived: 1}, "weekend")
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+
+	file, err := wau.ExportByTag(ctx, "weekend", "txt", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	defer file.Body.Close()
+	body, _ := io.ReadAll(file.Body)
+	if file.Name != "wallabag-weekend.txt" || !strings.Contains(string(body), "First") ||
+		!strings.Contains(string(body), "Second") || strings.Contains(string(body), "Read") {
+		t.Errorf("Unexpected export %s: %s", file.Name, body)
+	}
+	if _, err := wau.ExportByTag(ctx, "missing", "txt", 10); err == nil {
+		t.Error("Export of unknown tag should fail")
+	}
+}
+
+func TestWallabagUnavailable(t *testing.T) {
+	fake := wallabagtest.New()
+	fake.Err = wallabag.ErrServerError
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+
+	if _, err := wau.FindRecent(context.Background(), 5); !errors.Is(err, wallabag.ErrServerError) {
+		t.Errorf("Expected ErrServerError, got %v", err)
+	}
+}
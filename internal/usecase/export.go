@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/ebook"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+// bundleFormats can be produced for several entries at once:
+// epub is assembled locally, text formats are concatenated exports.
+var bundleFormats = []string{"epub", "md", "txt"}
+
+func (wau *WallabotArticleUseCase) Export(ctx context.Context, entryID int, format string) (ExportedFile, error) {
+	format = strings.ToLower(format)
+	if !slices.Contains(wallabag.ExportFormats, format) {
+		return ExportedFile{}, fmt.Errorf("unsupported export format %s, use one of: %s", format, strings.Join(wallabag.ExportFormats, ", "))
+	}
+	body, name, err := wau.wc.ExportArticle(ctx, entryID, format)
+	if err != nil {
+		return ExportedFile{}, err
+	}
+	return ExportedFile{Name: name, Body: body}, nil
+}
+
+func (wau *WallabotArticleUseCase) ExportByTag(ctx context.Context, tag string, format string, count int) (ExportedFile, error) {
+	format = strings.ToLower(format)
+	if !slices.Contains(bundleFormats, format) {
+		return ExportedFile{}, fmt.Errorf("unsupported bundle format %s, use one of: %s", format, strings.Join(bundleFormats, ", "))
+	}
+	entries, err := wau.wc.FetchArticles(ctx, 1, count, 0, []string{tag})
+	if err != nil {
+		return ExportedFile{}, err
+	}
+	if len(entries) == 0 {
+		return ExportedFile{}, fmt.Errorf("no unread articles with tag %s", tag)
+	}
+
+	title := fmt.Sprintf("Wallabag: %s", tag)
+	name := fmt.Sprintf("wallabag-%s.%s", strings.ReplaceAll(tag, " ", "-"), format)
+
+	var buf bytes.Buffer
+	if format == "epub" {
+		chapters := make([]ebook.Chapter, len(entries))
+		for i, entry := range entries {
+			chapters[i] = ebook.Chapter{Title: entry.Title, URL: entry.Url, Content: entryContent(entry)}
+		}
+		if err := ebook.WriteEPUB(&buf, title, chapters); err != nil {
+			return ExportedFile{}, err
+		}
+		return ExportedFile{Name: name, Body: io.NopCloser(&buf)}, nil
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		body, _, err := wau.wc.ExportArticle(ctx, entry.ID, format)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", entry.ID, err))
+			continue
+		}
+		_, err = io.Copy(&buf, body)
+		body.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", entry.ID, err))
+			continue
+		}
+		buf.WriteString("\n\n")
+	}
+	if buf.Len() == 0 {
+		return ExportedFile{}, errors.Join(errs...)
+	}
+	return ExportedFile{Name: name, Body: io.NopCloser(&buf)}, nil
+}
@@ -1,28 +1,51 @@
 package usecase
 
 import (
+	"context"
+	"io"
 	"strings"
 	"time"
 
+	"github.com/vanadium23/wallabag-telegram-bot/internal/rules"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/summarization"
 	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
 )
 
 type ArticleUseCase interface {
-	MarkRead(entryID int) (WallabotArticle, error)
-	MarkUnread(entryID int) (WallabotArticle, error)
-	MarkScrolled(entryID int) (WallabotArticle, error)
-	// DeleteScrolled(entryID int) (WallabotArticle, error)
-	AddRating(entryID int, rating string) (WallabotArticle, error)
-	// DeleteRating(entryID int) (WallabotArticle, error)
-	// Summarize(entryID int) (string, error)
-
-	FindByID(entryID int) (WallabotArticle, error)
-	SaveForLater(url string) (WallabotArticle, error)
-	FindRandom(count int) ([]WallabotArticle, error)
-	FindRecent(count int) ([]WallabotArticle, error)
-	FindShort(count int) ([]WallabotArticle, error)
-
-	GetStats() (WallabagStats, error)
+	MarkRead(ctx context.Context, entryID int) (WallabotArticle, error)
+	MarkUnread(ctx context.Context, entryID int) (WallabotArticle, error)
+	MarkScrolled(ctx context.Context, entryID int) (WallabotArticle, error)
+	// DeleteScrolled(ctx context.Context, entryID int) (WallabotArticle, error)
+	AddRating(ctx context.Context, entryID int, rating string) (WallabotArticle, error)
+	// DeleteRating(ctx context.Context, entryID int) (WallabotArticle, error)
+	Summarize(ctx context.Context, entryID int, opts summarization.Options) (string, error)
+
+	FindByID(ctx context.Context, entryID int) (WallabotArticle, error)
+	SaveForLater(ctx context.Context, url string) (WallabotArticle, error)
+	FindRandom(ctx context.Context, count int) ([]WallabotArticle, error)
+	FindRecent(ctx context.Context, count int) ([]WallabotArticle, error)
+	FindShort(ctx context.Context, count int) ([]WallabotArticle, error)
+
+	GetStats(ctx context.Context) (WallabagStats, error)
+
+	Export(ctx context.Context, entryID int, format string) (ExportedFile, error)
+	ExportByTag(ctx context.Context, tag string, format string, count int) (ExportedFile, error)
+
+	ListRules() []rules.Rule
+	TestRules(ctx context.Context, url string) (rules.Result, error)
+
+	BackfillReadingTime(ctx context.Context) (int, error)
+
+	SyncEmbeddings(ctx context.Context) (int, error)
+	Search(ctx context.Context, question string, count int) ([]WallabotArticle, error)
+	Related(ctx context.Context, entryID int, offset int, count int) ([]WallabotArticle, int, error)
+}
+
+// ExportedFile is a document ready to be sent to the chat,
+// Body must be closed by the receiver.
+type ExportedFile struct {
+	Name string
+	Body io.ReadCloser
 }
 
 type WallabagStats struct {
@@ -44,6 +67,8 @@ type WallabotArticle struct {
 
 	HasRating bool
 	Scrolled  bool
+	// AlreadySaved is set when SaveForLater found an existing entry
+	AlreadySaved bool
 }
 
 func NewWallabotArticle(entry wallabag.WallabagEntry) WallabotArticle {
@@ -68,7 +93,7 @@ func NewWallabotArticle(entry wallabag.WallabagEntry) WallabotArticle {
 		IsRead:      entry.IsArchived != 0,
 		tags:        tags,
 		Url:         entry.Url,
-		Content:     entry.Content,
+		Content:     entryContent(entry),
 		Title:       entry.Title,
 		CreatedAt:   entry.CreatedAt.Time,
 		ReadingTime: entry.ReadingTime,
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+const (
+	shortTag  = "short"
+	mediumTag = "medium"
+	longTag   = "long"
+
+	backfillPageSize = 100
+)
+
+var readingTimeTags = []string{shortTag, mediumTag, longTag}
+
+// ReadingTimeBuckets holds upper bounds in minutes for short and medium
+// entries, everything above MediumMax is long.
+type ReadingTimeBuckets struct {
+	ShortMax  int
+	MediumMax int
+}
+
+func DefaultReadingTimeBuckets() ReadingTimeBuckets {
+	return ReadingTimeBuckets{ShortMax: 5, MediumMax: 15}
+}
+
+// Tag returns bucket tag for the reading time, empty when it is unknown.
+func (b ReadingTimeBuckets) Tag(readingTime int) string {
+	switch {
+	case readingTime <= 0:
+		return ""
+	case readingTime <= b.ShortMax:
+		return shortTag
+	case readingTime <= b.MediumMax:
+		return mediumTag
+	default:
+		return longTag
+	}
+}
+
+// BackfillReadingTime walks the whole library and tags entries which have
+// no reading-time bucket yet. Entries which fail to update are skipped and
+// picked up by the next run. It returns the number of updated entries.
+func (wau *WallabotArticleUseCase) BackfillReadingTime(ctx context.Context) (int, error) {
+	updated := 0
+	for _, archive := range []int{0, 1} {
+		for page := 1; ; page++ {
+			entries, err := wau.wc.FetchArticlesWithSince(ctx, page, backfillPageSize, archive, 0, nil, "metadata")
+			// wallabag answers 404 for a page past the last one
+			if page > 1 && errors.Is(err, wallabag.ErrNotFound) {
+				break
+			}
+			if err != nil {
+				return updated, err
+			}
+			if len(entries) == 0 {
+				break
+			}
+			for _, entry := range entries {
+				tag := wau.buckets.Tag(entry.ReadingTime)
+				if tag == "" || hasAnyTag(entry.Tags, readingTimeTags) {
+					continue
+				}
+				wau.mxs[entry.ID%mxPool].Lock()
+				_, err := wau.wc.AddTagsToArticle(ctx, entry.ID, []string{tag})
+				wau.mxs[entry.ID%mxPool].Unlock()
+				if ctx.Err() != nil {
+					return updated, ctx.Err()
+				}
+				if err != nil {
+					log.Printf("error on tagging reading time of entry %d: %v\n", entry.ID, err)
+					continue
+				}
+				updated++
+			}
+			if len(entries) < backfillPageSize {
+				break
+			}
+		}
+	}
+	return updated, nil
+}
+
+func hasAnyTag(tags []wallabag.WallabagTag, labels []string) bool {
+	for _, tag := range tags {
+		if slices.Contains(labels, tag.Label) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+// relatedLimit bounds candidates ranked for a single entry.
+const relatedLimit = 20
+
+type relatedCandidate struct {
+	id    int
+	score float32
+	entry wallabag.WallabagEntry
+}
+
+// Related ranks entries sharing tags with the given one or close to it by
+// meaning, when semantic search is configured. It returns count articles
+// starting from offset and the number of ranked entries.
+func (wau *WallabotArticleUseCase) Related(ctx context.Context, entryID int, offset int, count int) ([]WallabotArticle, int, error) {
+	entry, err := wau.wc.FetchArticle(ctx, entryID)
+	if err != nil {
+		return nil, 0, err
+	}
+	candidates := map[int]*relatedCandidate{}
+
+	tags := wau.tags.Topical(entry)
+	for _, tag := range tags {
+		for _, archive := range []int{0, 1} {
+			entries, err := wau.wc.FetchArticles(ctx, 1, relatedLimit, archive, []string{tag})
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, e := range entries {
+				if e.ID == entryID {
+					continue
+				}
+				c, ok := candidates[e.ID]
+				if !ok {
+					c = &relatedCandidate{id: e.ID, entry: e}
+					candidates[e.ID] = c
+				}
+				// every tag of the entry shared adds the same weight
+				c.score += 1 / float32(len(tags))
+			}
+		}
+	}
+
+	if wau.index != nil {
+		matches, err := wau.index.Similar(entryID, relatedLimit)
+		if err != nil {
+			log.Printf("error on finding similar entries of %d: %v\n", entryID, err)
+		}
+		for _, match := range matches {
+			c, ok := candidates[match.ID]
+			if !ok {
+				c = &relatedCandidate{id: match.ID}
+				candidates[match.ID] = c
+			}
+			c.score += match.Score
+		}
+	}
+
+	ranked := make([]*relatedCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, c)
+	}
+	slices.SortFunc(ranked, func(a, b *relatedCandidate) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		}
+		// newer entries first
+		return b.id - a.id
+	})
+	ranked = ranked[:min(relatedLimit, len(ranked))]
+
+	var articles []WallabotArticle
+	for _, c := range ranked[min(offset, len(ranked)):min(offset+count, len(ranked))] {
+		if c.entry.ID == 0 {
+			// found only by embeddings
+			c.entry, err = wau.wc.FetchArticle(ctx, c.id)
+			if errors.Is(err, wallabag.ErrNotFound) {
+				log.Printf("entry %d was deleted, removing it from index\n", c.id)
+				wau.index.Remove(c.id)
+				continue
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		articles = append(articles, NewWallabotArticle(c.entry))
+	}
+	return articles, len(ranked), nil
+}
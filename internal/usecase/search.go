@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/embeddings"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+const syncPageSize = 50
+
+var errNoIndex = errors.New("semantic search is not configured")
+
+// ErrIndexing is returned by Search while nothing is indexed yet.
+var ErrIndexing = errors.New("library is being indexed for search")
+
+// SyncEmbeddings embeds entries changed since the previous sync and returns
+// their number. The first sync walks the whole library.
+func (wau *WallabotArticleUseCase) SyncEmbeddings(ctx context.Context) (int, error) {
+	if wau.index == nil {
+		return 0, errNoIndex
+	}
+	wau.syncMx.Lock()
+	defer wau.syncMx.Unlock()
+	return wau.syncEmbeddings(ctx)
+}
+
+// syncInBackground starts a sync unless one is running already, it
+// outlives the request which started it.
+func (wau *WallabotArticleUseCase) syncInBackground(ctx context.Context) {
+	if !wau.syncMx.TryLock() {
+		return
+	}
+	go func() {
+		defer wau.syncMx.Unlock()
+		synced, err := wau.syncEmbeddings(context.WithoutCancel(ctx))
+		if err != nil {
+			log.Printf("error on syncing embeddings: %v\n", err)
+		} else if synced > 0 {
+			log.Printf("embedded %d entries\n", synced)
+		}
+	}()
+}
+
+func (wau *WallabotArticleUseCase) syncEmbeddings(ctx context.Context) (int, error) {
+	started := time.Now().Unix()
+	since := wau.index.Since()
+	synced := 0
+	for _, archive := range []int{0, 1} {
+		for page := 1; ; page++ {
+			entries, err := wau.wc.FetchArticlesWithSince(ctx, page, syncPageSize, archive, since, nil, "full")
+			if err != nil {
+				return synced, err
+			}
+			docs := make([]embeddings.Document, len(entries))
+			for i, entry := range entries {
+				docs[i] = embeddings.Document{ID: entry.ID, Title: entry.Title, Content: entryContent(entry)}
+			}
+			if err := wau.index.Add(ctx, docs); err != nil {
+				return synced, err
+			}
+			synced += len(docs)
+			if len(entries) < syncPageSize {
+				break
+			}
+		}
+	}
+	return synced, wau.index.SetSince(started)
+}
+
+// Search finds entries closest to the question by meaning among already
+// indexed ones, entries changed since the last sync are embedded meanwhile.
+func (wau *WallabotArticleUseCase) Search(ctx context.Context, question string, count int) ([]WallabotArticle, error) {
+	if wau.index == nil {
+		return nil, errNoIndex
+	}
+	wau.syncInBackground(ctx)
+	if wau.index.Len() == 0 && wau.index.Since() == 0 {
+		return nil, ErrIndexing
+	}
+	// some matches may be deleted from wallabag already
+	matches, err := wau.index.Search(ctx, question, count*2)
+	if err != nil {
+		return nil, err
+	}
+	var articles []WallabotArticle
+	for _, match := range matches {
+		if len(articles) == count {
+			break
+		}
+		entry, err := wau.wc.FetchArticle(ctx, match.ID)
+		if errors.Is(err, wallabag.ErrNotFound) {
+			log.Printf("entry %d was deleted, removing it from index\n", match.ID)
+			wau.index.Remove(match.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, NewWallabotArticle(entry))
+	}
+	return articles, nil
+}
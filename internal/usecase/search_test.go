@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/embeddings"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/extract"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/storage"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag/wallabagtest"
+)
+
+// keywordEmbedder maps texts to counts of a few keywords.
+type keywordEmbedder struct{}
+
+func (keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		for _, keyword := range []string{"golang", "cooking"} {
+			vectors[i] = append(vectors[i], float32(strings.Count(strings.ToLower(text), keyword)))
+		}
+	}
+	return vectors, nil
+}
+
+func (keywordEmbedder) EmbeddingModel() string {
+	return "keywords"
+}
+
+func TestSearchDoesNotWaitForSync(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	golang := fake.Add(wallabag.WallabagEntry{Title: "Golang tips", Content: "<p>golang</p>"})
+	fake.Add(wallabag.WallabagEntry{Title: "Pasta", Content: "<p>cooking</p>"})
+	store, err := storage.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wau := newTestUseCase(t, fake, &fakeTagger{}, nil)
+	wau.index = embeddings.NewIndex(keywordEmbedder{}, store, extract.Options{})
+
+	if _, err := wau.Search(ctx, "golang", 1); !errors.Is(err, ErrIndexing) {
+		t.Fatalf("Expected ErrIndexing before the first sync, got %v", err)
+	}
+	// waits for the sync started by search
+	if _, err := wau.SyncEmbeddings(ctx); err != nil {
+		t.Fatal(err)
+	}
+	articles, err := wau.Search(ctx, "golang", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if len(articles) != 1 || articles[0].ID != golang.ID {
+		t.Errorf("Unexpected articles %v", articles)
+	}
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/summarization"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+// Where generated summaries are kept in wallabag.
+const (
+	SummaryStorageNone       = "none"
+	SummaryStorageAnnotation = "annotation"
+	SummaryStorageContent    = "content"
+)
+
+var SummaryStorages = []string{SummaryStorageNone, SummaryStorageAnnotation, SummaryStorageContent}
+
+// summaryBlock is prepended to content, summary paragraph holds escaped
+// text with <br> for line breaks.
+var (
+	summaryBlock = regexp.MustCompile(`(?s)<div class="wallabot-summary">\s*<p><b>(.*?)</b></p>\s*<p>(.*?)</p>.*?</div>`)
+	lineBreak    = regexp.MustCompile(`<br\s*/?>`)
+)
+
+// Summarize returns summary stored in wallabag for the same options or
+// generates and stores a new one.
+func (wau *WallabotArticleUseCase) Summarize(ctx context.Context, entryID int, opts summarization.Options) (string, error) {
+	if wau.summarizer == nil {
+		return "", errors.New("summarization is not configured")
+	}
+	entry, err := wau.wc.FetchArticle(ctx, entryID)
+	if err != nil {
+		return "", err
+	}
+	header := summaryHeader(opts)
+	if summary, ok := wau.storedSummary(ctx, entry, header); ok {
+		return summary, nil
+	}
+
+	summary, err := wau.summarizer.Summarize(ctx, entry.Title, entryContent(entry), opts)
+	if err != nil {
+		return "", err
+	}
+	if err := wau.storeSummary(ctx, entry, header, summary); err != nil {
+		// summary is still useful in the chat
+		log.Printf("error on storing summary of entry %d: %v\n", entryID, err)
+	}
+	return summary, nil
+}
+
+func (wau *WallabotArticleUseCase) storedSummary(ctx context.Context, entry wallabag.WallabagEntry, header string) (string, bool) {
+	switch wau.summaryStorage {
+	case SummaryStorageAnnotation:
+		annotations, err := wau.wc.FetchAnnotations(ctx, entry.ID)
+		if err != nil {
+			log.Printf("error on fetching annotations of entry %d: %v\n", entry.ID, err)
+			return "", false
+		}
+		for _, annotation := range annotations {
+			if summary, ok := strings.CutPrefix(annotation.Text, header+"\n\n"); ok {
+				return summary, true
+			}
+		}
+	case SummaryStorageContent:
+		if m := summaryBlock.FindStringSubmatch(entry.Content); m != nil && html.UnescapeString(m[1]) == header {
+			return html.UnescapeString(lineBreak.ReplaceAllString(m[2], "\n")), true
+		}
+	}
+	return "", false
+}
+
+func (wau *WallabotArticleUseCase) storeSummary(ctx context.Context, entry wallabag.WallabagEntry, header string, summary string) error {
+	switch wau.summaryStorage {
+	case SummaryStorageAnnotation:
+		_, err := wau.wc.AddAnnotation(ctx, entry.ID, header+"\n\n"+summary, "")
+		return err
+	case SummaryStorageContent:
+		// a summary with other options is replaced
+		content := entryContent(entry)
+		block := fmt.Sprintf(`<div class="wallabot-summary"><p><b>%s</b></p><p>%s</p><hr></div>`,
+			html.EscapeString(header),
+			strings.ReplaceAll(html.EscapeString(summary), "\n", "<br>"),
+		)
+		_, err := wau.wc.UpdateContent(ctx, entry.ID, block+content)
+		return err
+	}
+	return nil
+}
+
+// entryContent is the article without the summary block, all consumers of
+// the content read it this way so the summary isn't taken for the article.
+func entryContent(entry wallabag.WallabagEntry) string {
+	return summaryBlock.ReplaceAllString(entry.Content, "")
+}
+
+// summaryHeader marks stored summaries, options are part of it so
+// a summary is reused only for the same settings.
+func summaryHeader(opts summarization.Options) string {
+	return fmt.Sprintf("🤖 Summary (%s)", opts)
+}
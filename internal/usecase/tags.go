@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/tagging"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+// TagCache keeps labels of the library, so tagging doesn't request
+// them for every saved link.
+type TagCache struct {
+	wc wallabag.WallabagAPI
+	// ttl after which labels are fetched again
+	ttl time.Duration
+	// limit of labels offered to the tagger
+	limit int
+	// defaultTags are added to every saved entry by wallabag
+	defaultTags []string
+
+	mx        sync.Mutex
+	labels    []string
+	fetchedAt time.Time
+}
+
+func NewTagCache(wc wallabag.WallabagAPI, ttl time.Duration, limit int, defaultTags []string) *TagCache {
+	return &TagCache{wc: wc, ttl: ttl, limit: limit, defaultTags: defaultTags}
+}
+
+// Labels returns all known labels, most used first. When wallabag is not
+// reachable the previous list is used.
+func (tc *TagCache) Labels(ctx context.Context) []string {
+	tc.mx.Lock()
+	defer tc.mx.Unlock()
+	if tc.fetchedAt.IsZero() || time.Since(tc.fetchedAt) > tc.ttl {
+		tags, err := tc.wc.FetchTags(ctx)
+		if err != nil {
+			log.Printf("error on fetching tags: %v\n", err)
+		} else {
+			tc.labels = sortLabels(tags)
+		}
+		// on error wait for the next period too, instead of failing every save,
+		// unless the request was just cancelled
+		if ctx.Err() == nil {
+			tc.fetchedAt = time.Now()
+		}
+	}
+	return tc.labels
+}
+
+// Candidates returns the most used topical labels for the tagger.
+func (tc *TagCache) Candidates(ctx context.Context) []string {
+	labels := tc.topical(ctx)
+	return labels[:min(tc.limit, len(labels))]
+}
+
+// topical drops labels describing state of entries rather than their
+// topic, the tagger must not assign them.
+func (tc *TagCache) topical(ctx context.Context) []string {
+	var labels []string
+	for _, label := range tc.Labels(ctx) {
+		if !isBotTag(label, tc.defaultTags) {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// Add remembers labels which were just assigned.
+func (tc *TagCache) Add(labels []string) {
+	tc.mx.Lock()
+	defer tc.mx.Unlock()
+	for _, label := range labels {
+		if !slices.Contains(tc.labels, label) {
+			tc.labels = append(tc.labels, label)
+		}
+	}
+}
+
+// Match replaces tags with existing topical labels of the same spelling.
+func (tc *TagCache) Match(ctx context.Context, tags []string) []string {
+	labels := tc.topical(ctx)
+	matched := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if label, ok := tagging.MatchTag(tag, labels); ok {
+			tag = label
+		}
+		if !slices.Contains(matched, tag) {
+			matched = append(matched, tag)
+		}
+	}
+	return matched
+}
+
+// Topical returns tags of the entry describing its topic, dropping ones set
+// by the bot or wallabag, which are shared by most of the entries.
+func (tc *TagCache) Topical(entry wallabag.WallabagEntry) []string {
+	var tags []string
+	for _, tag := range entry.Tags {
+		if isBotTag(tag.Label, tc.defaultTags) {
+			continue
+		}
+		tags = append(tags, tag.Label)
+	}
+	return tags
+}
+
+// serviceTags are set by the bot itself and say nothing about the topic.
+var serviceTags = []string{"autotag", "scrolled"}
+
+// isBotTag reports whether the label is set by the bot or wallabag rather
+// than describes the topic: service, rating, reading time and default tags.
+func isBotTag(label string, defaultTags []string) bool {
+	_, rating := RatingFromString(label)
+	return rating ||
+		slices.Contains(serviceTags, label) ||
+		slices.Contains(readingTimeTags, label) ||
+		slices.Contains(defaultTags, label)
+}
+
+// SplitTags parses comma separated list of tags, as wallabag accepts them.
+func SplitTags(list string) []string {
+	var tags []string
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// sortLabels orders labels by usage, keeping API order when wallabag
+// doesn't report counts.
+func sortLabels(tags []wallabag.WallabagTag) []string {
+	tags = slices.Clone(tags)
+	slices.SortStableFunc(tags, func(a, b wallabag.WallabagTag) int {
+		return b.NbEntries - a.NbEntries
+	})
+	labels := make([]string, len(tags))
+	for i, tag := range tags {
+		labels[i] = tag.Label
+	}
+	return labels
+}
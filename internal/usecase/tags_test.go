@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag/wallabagtest"
+)
+
+func TestTagCacheSkipsBotTags(t *testing.T) {
+	ctx := context.Background()
+	fake := wallabagtest.New()
+	fake.Add(wallabag.WallabagEntry{}, "golang", "wallabot", "autotag", "scrolled", "good", "short")
+	fake.Add(wallabag.WallabagEntry{}, "golang", "wallabot", "databases")
+	tc := NewTagCache(fake, time.Hour, 10, SplitTags(" wallabot, "))
+
+	if candidates := tc.Candidates(ctx); !slices.Equal(candidates, []string{"golang", "databases"}) {
+		t.Errorf("Unexpected candidates %v", candidates)
+	}
+	matched := tc.Match(ctx, []string{"Golang", "Scrolled", "goods"})
+	if !slices.Equal(matched, []string{"golang", "Scrolled", "goods"}) {
+		t.Errorf("Unexpected matched tags %v", matched)
+	}
+}
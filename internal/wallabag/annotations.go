@@ -0,0 +1,87 @@
+package wallabag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type WallabagAnnotationRange struct {
+	Start       string `json:"start"`
+	StartOffset int    `json:"startOffset"`
+	End         string `json:"end"`
+	EndOffset   int    `json:"endOffset"`
+}
+
+type WallabagAnnotation struct {
+	ID     int                       `json:"id,omitempty"`
+	Text   string                    `json:"text"`
+	Quote  string                    `json:"quote"`
+	Ranges []WallabagAnnotationRange `json:"ranges"`
+}
+
+type WallabagAnnotationsResponse struct {
+	Total int                  `json:"total"`
+	Rows  []WallabagAnnotation `json:"rows"`
+}
+
+// FetchAnnotations returns annotations of the entry.
+func (wc WallabagClient) FetchAnnotations(ctx context.Context, entryID int) ([]WallabagAnnotation, error) {
+	url := fmt.Sprintf("%s/api/annotations/%d.json", wc.baseURL, entryID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := wc.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var response WallabagAnnotationsResponse
+	if err := decodeResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Rows, nil
+}
+
+// AddAnnotation attaches a note to the entry. Quote is the annotated part
+// of content and may be empty, then the note belongs to the entry itself.
+func (wc WallabagClient) AddAnnotation(ctx context.Context, entryID int, text string, quote string) (WallabagAnnotation, error) {
+	url := fmt.Sprintf("%s/api/annotations/%d.json", wc.baseURL, entryID)
+	data, _ := json.Marshal(WallabagAnnotation{
+		Text:   text,
+		Quote:  quote,
+		Ranges: []WallabagAnnotationRange{{}},
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(data))
+	if err != nil {
+		return WallabagAnnotation{}, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wc.do(req)
+	if err != nil {
+		return WallabagAnnotation{}, fmt.Errorf("failed to make request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, http.StatusOK, http.StatusCreated); err != nil {
+		return WallabagAnnotation{}, err
+	}
+
+	var annotation WallabagAnnotation
+	if err := decodeResponse(resp, &annotation); err != nil {
+		return WallabagAnnotation{}, err
+	}
+	return annotation, nil
+}
@@ -2,10 +2,15 @@ package wallabag
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	neturl "net/url"
 	"strings"
 	"time"
 )
@@ -19,6 +24,8 @@ type WallabagTag struct {
 	ID    int    `json:"id"`
 	Label string `json:"label"`
 	Slug  string `json:"slug"`
+	// NbEntries is reported only by /api/tags of recent wallabag versions
+	NbEntries int `json:"nb_entries,omitempty"`
 }
 
 type WallabagEntry struct {
@@ -32,12 +39,14 @@ type WallabagEntry struct {
 	Title       string        `json:"title"`
 	ReadingTime int           `json:"reading_time"`
 	IsArchived  int           `json:"is_archived"`
+	IsStarred   int           `json:"is_starred"`
 	Tags        []WallabagTag `json:"tags"`
 }
 
 type WallabagOauthToken struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 type WallabagEntryResponseItems struct {
@@ -48,10 +57,23 @@ type WallabagEntryResponse struct {
 	Data WallabagEntryResponseItems `json:"_embedded"`
 }
 
+type WallabagExistsResponse struct {
+	// Exists holds entry id when return_id is requested, null or false otherwise
+	Exists any `json:"exists"`
+}
+
 type WallabagUpdateEntryData struct {
 	Archive int `json:"archive"`
 }
 
+type WallabagContentEntryData struct {
+	Content string `json:"content"`
+}
+
+type WallabagStarEntryData struct {
+	Starred int `json:"starred"`
+}
+
 // WallabagTimeLayout is a variation of RFC3339 but without colons in
 // the timezone delimiter, breaking the RFC
 const WallabagTimeLayout = "2006-01-02T15:04:05-0700"
@@ -81,15 +103,8 @@ type WallabagClient struct {
 	baseURL     string
 	defaultTags string
 
-	// oAuth params
-	clientID     string
-	clientSecret string
-	username     string
-	password     string
-
-	// token state
-	accessToken        string
-	accessTokenExpires time.Time
+	// tokens are shared by copies of the client
+	tokens *tokenSource
 }
 
 func NewWallabagClient(
@@ -102,44 +117,14 @@ func NewWallabagClient(
 	defaultTags string,
 ) WallabagClient {
 	return WallabagClient{
-		client:             client,
-		baseURL:            baseURL,
-		clientID:           clientID,
-		clientSecret:       clientSecret,
-		username:           username,
-		password:           password,
-		accessTokenExpires: time.Now(),
-		defaultTags:        defaultTags,
+		client:      client,
+		baseURL:     baseURL,
+		defaultTags: defaultTags,
+		tokens:      newTokenSource(client, baseURL, clientID, clientSecret, username, password),
 	}
 }
 
-func (wc *WallabagClient) fetchAccessToken() (string, error) {
-	if time.Now().Before(wc.accessTokenExpires) && wc.accessToken != "" {
-		return wc.accessToken, nil
-	}
-	queryParams := fmt.Sprintf("?grant_type=password&client_id=%s&client_secret=%s&username=%s&password=%s",
-		wc.clientID, wc.clientSecret, wc.username, wc.password)
-	resp, err := wc.client.Get(wc.baseURL + "/oauth/v2/token" + queryParams)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("authentication failed with status %d: %s", resp.StatusCode, resp.Status)
-	}
-
-	var data WallabagOauthToken
-	err = json.NewDecoder(resp.Body).Decode(&data)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode token response: %w", err)
-	}
-	wc.accessTokenExpires = time.Now().Local().Add(time.Second * time.Duration(data.ExpiresIn))
-	wc.accessToken = data.AccessToken
-	return wc.accessToken, nil
-}
-
-func (wc WallabagClient) CreateArticle(articleURL string) (WallabagEntry, error) {
+func (wc WallabagClient) CreateArticle(ctx context.Context, articleURL string) (WallabagEntry, error) {
 	var createdEntry WallabagEntry
 
 	newEntry := WallabagCreateEntry{
@@ -147,177 +132,241 @@ func (wc WallabagClient) CreateArticle(articleURL string) (WallabagEntry, error)
 		Tags: wc.defaultTags,
 	}
 	data, _ := json.Marshal(newEntry)
-	req, err := http.NewRequest("POST", wc.baseURL+"/api/entries.json", bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "POST", wc.baseURL+"/api/entries.json", bytes.NewBuffer(data))
 
 	if err != nil {
 		return createdEntry, err
 	}
-	accessToken, err := wc.fetchAccessToken()
-	if err != nil {
-		return createdEntry, err
-	}
-
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 
-	resp, err := wc.client.Do(req)
+	resp, err := wc.do(req)
 	if err != nil {
 		return createdEntry, err
 	}
 	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&createdEntry)
-	if err != nil {
+	if err := checkResponse(resp); err != nil {
 		return createdEntry, err
 	}
+	err = decodeResponse(resp, &createdEntry)
 	return createdEntry, err
 }
 
-func (wc WallabagClient) FetchArticles(page int, perPage int, archive int, tags []string) ([]WallabagEntry, error) {
-	url := fmt.Sprintf("%s/api/entries.json?page=%d&perPage=%d&archive=%d", wc.baseURL, page, perPage, archive)
-	req, err := http.NewRequest("GET", url, nil)
+// EntryExists looks up entry by its URL and by SHA1 hash of the URL,
+// the latter is how wallabag stores urls since 2.4. Zero means no entry.
+func (wc WallabagClient) EntryExists(ctx context.Context, articleURL string) (int, error) {
+	hash := sha1.Sum([]byte(articleURL))
+	for _, query := range []string{
+		"url=" + neturl.QueryEscape(articleURL),
+		"hashed_url=" + hex.EncodeToString(hash[:]),
+	} {
+		entryID, err := wc.entryExists(ctx, query)
+		if err != nil || entryID != 0 {
+			return entryID, err
+		}
+	}
+	return 0, nil
+}
 
+func (wc WallabagClient) entryExists(ctx context.Context, query string) (int, error) {
+	url := fmt.Sprintf("%s/api/entries/exists.json?return_id=1&%s", wc.baseURL, query)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
-	}
-	accessToken, err := wc.fetchAccessToken()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get access token: %w", err)
+		return 0, err
 	}
 
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 
-	resp, err := wc.client.Do(req)
+	resp, err := wc.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request to %s: %w", url, err)
+		return 0, fmt.Errorf("failed to make request to %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s for URL: %s", resp.StatusCode, resp.Status, url)
+	if err := checkResponse(resp); err != nil {
+		return 0, err
+	}
+
+	var response WallabagExistsResponse
+	if err := decodeResponse(resp, &response); err != nil {
+		return 0, err
+	}
+	if id, ok := response.Exists.(float64); ok {
+		return int(id), nil
+	}
+	return 0, nil
+}
+
+func (wc WallabagClient) FetchArticles(ctx context.Context, page int, perPage int, archive int, tags []string) ([]WallabagEntry, error) {
+	url := fmt.Sprintf("%s/api/entries.json?page=%d&perPage=%d&archive=%d", wc.baseURL, page, perPage, archive)
+	if len(tags) > 0 {
+		url += "&tags=" + neturl.QueryEscape(strings.Join(tags, ","))
 	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
-	// Read response body for debugging
-	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body from %s: %w", url, err)
+		return nil, err
 	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
 
-	// Log response info for debugging
-	fmt.Printf("DEBUG: Response Status: %d, Content-Length: %d, Body Length: %d\n", resp.StatusCode, resp.ContentLength, len(bodyBytes))
-	fmt.Printf("DEBUG: Response Body Preview: %.200s...\n", string(bodyBytes))
+	resp, err := wc.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
 
-	// Create new reader from the body bytes
-	bodyReader := bytes.NewReader(bodyBytes)
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
 
 	var response WallabagEntryResponse
-	err = json.NewDecoder(bodyReader).Decode(&response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode response from %s: %w (Status: %d, Content-Length: %d, Body Length: %d)", url, err, resp.StatusCode, resp.ContentLength, len(bodyBytes))
+	if err := decodeResponse(resp, &response); err != nil {
+		return nil, err
 	}
 	return response.Data.Entries, nil
 }
 
-func (wc WallabagClient) FetchArticlesWithSince(page int, perPage int, archive int, since int64, tags []string, detail string) ([]WallabagEntry, error) {
+func (wc WallabagClient) FetchArticlesWithSince(ctx context.Context, page int, perPage int, archive int, since int64, tags []string, detail string) ([]WallabagEntry, error) {
 	if detail == "" {
 		detail = "full"
 	}
 	url := fmt.Sprintf("%s/api/entries.json?page=%d&perPage=%d&archive=%d&since=%d&detail=%s", wc.baseURL, page, perPage, archive, since, detail)
-	req, err := http.NewRequest("GET", url, nil)
+	if len(tags) > 0 {
+		url += "&tags=" + neturl.QueryEscape(strings.Join(tags, ","))
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	if err != nil {
 		return nil, err
 	}
-	accessToken, err := wc.fetchAccessToken()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get access token: %w", err)
-	}
-
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 
-	resp, err := wc.client.Do(req)
+	resp, err := wc.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request to %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s for URL: %s", resp.StatusCode, resp.Status, url)
+	if err := checkResponse(resp); err != nil {
+		return nil, err
 	}
 
 	var response WallabagEntryResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode response from %s: %w", url, err)
+	if err := decodeResponse(resp, &response); err != nil {
+		return nil, err
 	}
 	return response.Data.Entries, nil
 }
 
-func (wc WallabagClient) FetchArticle(entryID int) (WallabagEntry, error) {
+func (wc WallabagClient) FetchArticle(ctx context.Context, entryID int) (WallabagEntry, error) {
 	url := fmt.Sprintf("%s/api/entries/%d.json", wc.baseURL, entryID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return WallabagEntry{}, err
-	}
-
-	accessToken, err := wc.fetchAccessToken()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return WallabagEntry{}, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 
-	resp, err := wc.client.Do(req)
+	resp, err := wc.do(req)
 	if err != nil {
 		return WallabagEntry{}, err
 	}
-	var response WallabagEntry
-
 	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err := checkResponse(resp); err != nil {
+		return WallabagEntry{}, err
+	}
+	var response WallabagEntry
+	err = decodeResponse(resp, &response)
 
-	return response, nil
+	return response, err
 }
 
-func (wc WallabagClient) UpdateArticle(entryID int, archive int) (WallabagEntry, error) {
+func (wc WallabagClient) UpdateArticle(ctx context.Context, entryID int, archive int) (WallabagEntry, error) {
 	updateEntry := WallabagUpdateEntryData{
 		Archive: archive,
 	}
 	url := fmt.Sprintf("%s/api/entries/%d.json", wc.baseURL, entryID)
 	data, _ := json.Marshal(updateEntry)
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(data))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(data))
 	if err != nil {
 		return WallabagEntry{}, err
 	}
 
-	accessToken, err := wc.fetchAccessToken()
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wc.do(req)
+	if err != nil {
+		return WallabagEntry{}, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return WallabagEntry{}, err
+	}
+	var response WallabagEntry
+	err = decodeResponse(resp, &response)
+
+	return response, err
+}
+
+func (wc WallabagClient) StarArticle(ctx context.Context, entryID int, starred int) (WallabagEntry, error) {
+	starEntry := WallabagStarEntryData{
+		Starred: starred,
+	}
+	url := fmt.Sprintf("%s/api/entries/%d.json", wc.baseURL, entryID)
+	data, _ := json.Marshal(starEntry)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(data))
 	if err != nil {
 		return WallabagEntry{}, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 
-	resp, err := wc.client.Do(req)
+	resp, err := wc.do(req)
 	if err != nil {
 		return WallabagEntry{}, err
 	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return WallabagEntry{}, err
+	}
 	var response WallabagEntry
+	err = decodeResponse(resp, &response)
 
+	return response, err
+}
+
+// UpdateContent replaces stored content of the entry.
+func (wc WallabagClient) UpdateContent(ctx context.Context, entryID int, content string) (WallabagEntry, error) {
+	url := fmt.Sprintf("%s/api/entries/%d.json", wc.baseURL, entryID)
+	data, _ := json.Marshal(WallabagContentEntryData{Content: content})
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(data))
+	if err != nil {
+		return WallabagEntry{}, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wc.do(req)
+	if err != nil {
+		return WallabagEntry{}, err
+	}
 	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err := checkResponse(resp); err != nil {
+		return WallabagEntry{}, err
+	}
+	var response WallabagEntry
+	err = decodeResponse(resp, &response)
 
-	return response, nil
+	return response, err
 }
 
-func (wc WallabagClient) AddTagsToArticle(entryID int, tags []string) (WallabagEntry, error) {
+func (wc WallabagClient) AddTagsToArticle(ctx context.Context, entryID int, tags []string) (WallabagEntry, error) {
 	data := map[string]string{
 		"tags": strings.Join(tags, ","),
 	}
@@ -326,28 +375,78 @@ func (wc WallabagClient) AddTagsToArticle(entryID int, tags []string) (WallabagE
 		return WallabagEntry{}, err
 	}
 	url := fmt.Sprintf("%s/api/entries/%d/tags.json", wc.baseURL, entryID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return WallabagEntry{}, err
-	}
-
-	accessToken, err := wc.fetchAccessToken()
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return WallabagEntry{}, err
 	}
 
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 
-	resp, err := wc.client.Do(req)
+	resp, err := wc.do(req)
 	if err != nil {
 		return WallabagEntry{}, err
 	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return WallabagEntry{}, err
+	}
 	var response WallabagEntry
+	err = decodeResponse(resp, &response)
+
+	return response, err
+}
+
+// ExportFormats lists formats supported by wallabag export endpoint.
+var ExportFormats = []string{"epub", "mobi", "pdf", "txt", "md", "csv", "json", "xml"}
+
+// ExportArticle downloads the entry rendered by wallabag in the given format.
+// The caller is responsible for closing returned body.
+func (wc WallabagClient) ExportArticle(ctx context.Context, entryID int, format string) (io.ReadCloser, string, error) {
+	url := fmt.Sprintf("%s/api/entries/%d/export.%s", wc.baseURL, entryID, format)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := wc.do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to make request to %s: %w", url, err)
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, "", err
+	}
 
+	filename := fmt.Sprintf("entry-%d.%s", entryID, format)
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		filename = params["filename"]
+	}
+	return resp.Body, filename, nil
+}
+
+// FetchTags returns all tags of the user.
+func (wc WallabagClient) FetchTags(ctx context.Context) ([]WallabagTag, error) {
+	url := fmt.Sprintf("%s/api/tags.json", wc.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := wc.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to %s: %w", url, err)
+	}
 	defer resp.Body.Close()
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
 
-	return response, nil
+	var tags []WallabagTag
+	if err := decodeResponse(resp, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
 }
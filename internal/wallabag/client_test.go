@@ -1,12 +1,17 @@
 package wallabag
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestWallabagClientCreateArticle(t *testing.T) {
@@ -42,7 +47,7 @@ func TestWallabagClientCreateArticle(t *testing.T) {
 			if data.Tags != articleTags {
 				t.Errorf("Provided tags are not equal %s == %s", data.Tags, articleTags)
 			}
-			response, _ := json.Marshal(data)
+			response, _ := json.Marshal(WallabagEntry{Url: data.Url})
 			rw.Write(response)
 		case "/oauth/v2/token":
 			data := WallabagOauthToken{
@@ -67,7 +72,7 @@ func TestWallabagClientCreateArticle(t *testing.T) {
 		Password,
 		"source:wallabag",
 	)
-	article, err := wallabagClient.CreateArticle(articleURL)
+	article, err := wallabagClient.CreateArticle(context.Background(), articleURL)
 	if err != nil {
 		t.Errorf("Unexpected error during %s", err)
 	}
@@ -109,7 +114,8 @@ func TestWallabagClientUpdateArticle(t *testing.T) {
 				t.Errorf("Wrong update come to server")
 			}
 
-			rw.Write([]byte(""))
+			response, _ := json.Marshal(WallabagEntry{ID: entryID, IsArchived: data.Archive})
+			rw.Write(response)
 		case "/oauth/v2/token":
 			data := WallabagOauthToken{
 				AccessToken: "access_token",
@@ -133,7 +139,7 @@ func TestWallabagClientUpdateArticle(t *testing.T) {
 		Password,
 		"",
 	)
-	_, err := wallabagClient.UpdateArticle(entryID, archive)
+	_, err := wallabagClient.UpdateArticle(context.Background(), entryID, archive)
 	if err != nil {
 		t.Errorf("Unexpected error during %s", err)
 	}
@@ -211,7 +217,7 @@ func TestWallabagClientFetchArticles(t *testing.T) {
 		Password,
 		"",
 	)
-	articles, err := wallabagClient.FetchArticles(page, perPage, archive, []string{})
+	articles, err := wallabagClient.FetchArticles(context.Background(), page, perPage, archive, []string{})
 	if err != nil {
 		t.Errorf("Unexpected error during %s", err)
 	}
@@ -219,3 +225,350 @@ func TestWallabagClientFetchArticles(t *testing.T) {
 		t.Errorf("No articles fetched")
 	}
 }
+
+func TestWallabagClientExportArticle(t *testing.T) {
+	ClientID := "app_xxx"
+	ClientSecret := "secret_xxx"
+	Username := "unit"
+	Password := "password"
+	AccessToken := "access_token"
+
+	entryID := 1000
+	content := "epub content"
+
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		exportPath := fmt.Sprintf("/api/entries/%d/export.epub", entryID)
+		switch path {
+		case exportPath:
+			bearer := req.Header.Get("Authorization")
+			if bearer != fmt.Sprintf("Bearer %s", AccessToken) {
+				http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+				t.Errorf("No bearer token in request")
+				return
+			}
+			rw.Header().Set("Content-Disposition", `attachment; filename="Some article.epub"`)
+			rw.Write([]byte(content))
+		case "/oauth/v2/token":
+			data := WallabagOauthToken{
+				AccessToken: "access_token",
+				ExpiresIn:   24 * 60 * 60,
+			}
+			response, _ := json.Marshal(data)
+			rw.Write(response)
+		default:
+			http.NotFound(rw, req)
+		}
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(
+		server.Client(),
+		server.URL,
+		ClientID,
+		ClientSecret,
+		Username,
+		Password,
+		"",
+	)
+	body, filename, err := wallabagClient.ExportArticle(context.Background(), entryID, "epub")
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	if string(data) != content {
+		t.Errorf("Unexpected export content %s", data)
+	}
+	if filename != "Some article.epub" {
+		t.Errorf("Unexpected filename %s", filename)
+	}
+
+	_, _, err = wallabagClient.ExportArticle(context.Background(), entryID+1, "epub")
+	if err == nil {
+		t.Errorf("Expected error for missing entry")
+	}
+}
+
+func TestWallabagClientEntryExists(t *testing.T) {
+	ClientID := "app_xxx"
+	ClientSecret := "secret_xxx"
+	Username := "unit"
+	Password := "password"
+
+	savedURL := "https://example.com/saved"
+	hashedURL := "https://example.com/hashed"
+	hashedValue := "1f1708d89d87e3e41774ebb1b17d9f696bb86673"
+
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		switch path {
+		case "/api/entries/exists.json":
+			query := req.URL.Query()
+			if query.Get("return_id") != "1" {
+				t.Errorf("Entry id was not requested")
+			}
+			switch {
+			case query.Get("url") == savedURL:
+				rw.Write([]byte(`{"exists": 10}`))
+			case query.Get("hashed_url") == hashedValue:
+				rw.Write([]byte(`{"exists": 20}`))
+			default:
+				rw.Write([]byte(`{"exists": null}`))
+			}
+		case "/oauth/v2/token":
+			data := WallabagOauthToken{
+				AccessToken: "access_token",
+				ExpiresIn:   24 * 60 * 60,
+			}
+			response, _ := json.Marshal(data)
+			rw.Write(response)
+		default:
+			t.Errorf("Incorrect path %s", path)
+		}
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(
+		server.Client(),
+		server.URL,
+		ClientID,
+		ClientSecret,
+		Username,
+		Password,
+		"",
+	)
+	for articleURL, expected := range map[string]int{
+		savedURL:                     10,
+		hashedURL:                    20,
+		"https://example.com/absent": 0,
+	} {
+		entryID, err := wallabagClient.EntryExists(context.Background(), articleURL)
+		if err != nil {
+			t.Errorf("Unexpected error during %s", err)
+		}
+		if entryID != expected {
+			t.Errorf("Unexpected entry for %s: %d != %d", articleURL, entryID, expected)
+		}
+	}
+}
+
+func TestWallabagClientFetchTags(t *testing.T) {
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		switch path {
+		case "/api/tags.json":
+			rw.Write([]byte(`[{"id": 1, "label": "golang", "slug": "golang", "nb_entries": 12}, {"id": 2, "label": "system design", "slug": "system-design"}]`))
+		case "/oauth/v2/token":
+			data := WallabagOauthToken{
+				AccessToken: "access_token",
+				ExpiresIn:   24 * 60 * 60,
+			}
+			response, _ := json.Marshal(data)
+			rw.Write(response)
+		default:
+			t.Errorf("Incorrect path %s", path)
+		}
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+	tags, err := wallabagClient.FetchTags(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if len(tags) != 2 || tags[0].Label != "golang" || tags[0].NbEntries != 12 || tags[1].Slug != "system-design" {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+}
+
+func TestWallabagClientAnnotations(t *testing.T) {
+	var stored []WallabagAnnotation
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		switch path {
+		case "/api/annotations/42.json":
+			if req.Method == "POST" {
+				var annotation WallabagAnnotation
+				if err := json.NewDecoder(req.Body).Decode(&annotation); err != nil {
+					http.Error(rw, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if len(annotation.Ranges) != 1 {
+					t.Errorf("Annotation without ranges %v", annotation)
+				}
+				annotation.ID = len(stored) + 1
+				stored = append(stored, annotation)
+				response, _ := json.Marshal(annotation)
+				rw.Write(response)
+				return
+			}
+			response, _ := json.Marshal(WallabagAnnotationsResponse{Total: len(stored), Rows: stored})
+			rw.Write(response)
+		case "/oauth/v2/token":
+			data := WallabagOauthToken{
+				AccessToken: "access_token",
+				ExpiresIn:   24 * 60 * 60,
+			}
+			response, _ := json.Marshal(data)
+			rw.Write(response)
+		default:
+			t.Errorf("Incorrect path %s", path)
+		}
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+	annotation, err := wallabagClient.AddAnnotation(context.Background(), 42, "Summary", "")
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if annotation.ID != 1 {
+		t.Errorf("Unexpected annotation %v", annotation)
+	}
+	annotations, err := wallabagClient.FetchAnnotations(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if len(annotations) != 1 || annotations[0].Text != "Summary" {
+		t.Errorf("Unexpected annotations %v", annotations)
+	}
+}
+
+func TestWallabagClientUpdateContent(t *testing.T) {
+	// Start a local HTTP server
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		switch path {
+		case "/api/entries/42.json":
+			if req.Method != "PATCH" {
+				t.Errorf("Unexpected method %s", req.Method)
+			}
+			var data WallabagContentEntryData
+			if err := json.NewDecoder(req.Body).Decode(&data); err != nil {
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			response, _ := json.Marshal(WallabagEntry{ID: 42, Content: data.Content})
+			rw.Write(response)
+		case "/oauth/v2/token":
+			data := WallabagOauthToken{
+				AccessToken: "access_token",
+				ExpiresIn:   24 * 60 * 60,
+			}
+			response, _ := json.Marshal(data)
+			rw.Write(response)
+		default:
+			t.Errorf("Incorrect path %s", path)
+		}
+	}))
+	// Close the server when test finishes
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+	entry, err := wallabagClient.UpdateContent(context.Background(), 42, "<p>new</p>")
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if entry.Content != "<p>new</p>" {
+		t.Errorf("Unexpected entry %v", entry)
+	}
+}
+
+func TestWallabagClientContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/oauth/v2/token":
+			response, _ := json.Marshal(WallabagOauthToken{AccessToken: "access_token", ExpiresIn: 60})
+			rw.Write(response)
+		default:
+			// hung wallabag
+			<-release
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := wallabagClient.FetchArticle(ctx, 42)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+}
+
+func TestWallabagClientTypedErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		tokenErr bool
+		expected error
+	}{
+		{name: "not found", status: http.StatusNotFound, body: `{"error":{"code":404,"message":"Not Found"}}`, expected: ErrNotFound},
+		{name: "expired token", status: http.StatusUnauthorized, body: `{"error":"invalid_grant"}`, expected: ErrUnauthorized},
+		{name: "wrong password", status: http.StatusBadRequest, body: `{"error":"invalid_grant"}`, tokenErr: true, expected: ErrUnauthorized},
+		{name: "rate limit", status: http.StatusTooManyRequests, body: "slow down", expected: ErrRateLimited},
+		{name: "server error", status: http.StatusBadGateway, body: "<html>Bad Gateway</html>", expected: ErrServerError},
+		{name: "broken json", status: http.StatusOK, body: "<html>maintenance</html>", expected: ErrDecode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/oauth/v2/token" && !tt.tokenErr {
+					response, _ := json.Marshal(WallabagOauthToken{AccessToken: "access_token", ExpiresIn: 60})
+					rw.Write(response)
+					return
+				}
+				rw.WriteHeader(tt.status)
+				rw.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+			_, err := wallabagClient.FetchArticle(context.Background(), 42)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, err)
+			}
+			if !strings.Contains(err.Error(), tt.body) {
+				t.Errorf("Error should contain body excerpt, got %v", err)
+			}
+			if strings.Contains(err.Error(), "password") {
+				t.Errorf("Error should not contain credentials, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWallabagClientCutsLongErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/oauth/v2/token" {
+			response, _ := json.Marshal(WallabagOauthToken{AccessToken: "access_token", ExpiresIn: 60})
+			rw.Write(response)
+			return
+		}
+		rw.WriteHeader(http.StatusBadGateway)
+		rw.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+	_, err := wallabagClient.FetchArticle(context.Background(), 42)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected StatusError, got %v", err)
+	}
+	if statusErr.Body != strings.Repeat("x", excerptLength)+"…" {
+		t.Errorf("Unexpected body excerpt %q", statusErr.Body)
+	}
+}
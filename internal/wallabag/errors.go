@@ -0,0 +1,126 @@
+package wallabag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of failed requests, match them with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found in wallabag")
+	ErrUnauthorized = errors.New("wallabag rejected credentials")
+	ErrRateLimited  = errors.New("wallabag rate limit is exceeded")
+	ErrServerError  = errors.New("wallabag server error")
+	ErrDecode       = errors.New("unexpected wallabag response")
+)
+
+// excerptLength bounds response body kept in errors.
+const excerptLength = 200
+
+// StatusError is returned when wallabag answers with unexpected status.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s failed with status %d: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// DecodeError is returned when response body is not what was expected.
+type DecodeError struct {
+	URL        string
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response from %s (status %d): %v: %s", e.URL, e.StatusCode, e.Err, e.Body)
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// checkResponse returns StatusError unless status is one of expected,
+// 200 OK by default.
+func checkResponse(resp *http.Response, expected ...int) error {
+	if len(expected) == 0 {
+		expected = []int{http.StatusOK}
+	}
+	if slices.Contains(expected, resp.StatusCode) {
+		return nil
+	}
+	// one more byte tells whether the excerpt is cut
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, excerptLength+1))
+	return &StatusError{
+		Method:     resp.Request.Method,
+		URL:        requestURL(resp),
+		StatusCode: resp.StatusCode,
+		Body:       excerpt(body),
+	}
+}
+
+// decodeResponse decodes JSON body into v.
+func decodeResponse(resp *http.Response, v any) error {
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+	if err != nil {
+		return &DecodeError{
+			URL:        requestURL(resp),
+			StatusCode: resp.StatusCode,
+			Body:       excerpt(body),
+			Err:        err,
+		}
+	}
+	return nil
+}
+
+// requestURL drops query, the token request carries credentials in it.
+func requestURL(resp *http.Response) string {
+	u := *resp.Request.URL
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
+
+func excerpt(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) <= excerptLength {
+		return s
+	}
+	cut := excerptLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
@@ -0,0 +1,32 @@
+package wallabag
+
+import (
+	"context"
+	"io"
+)
+
+// WallabagAPI is the part of wallabag used by the bot, WallabagClient
+// implements it over HTTP and wallabagtest.Fake in memory.
+type WallabagAPI interface {
+	CreateArticle(ctx context.Context, articleURL string) (WallabagEntry, error)
+	// EntryExists returns id of the entry saved with the URL, zero if there is none.
+	EntryExists(ctx context.Context, articleURL string) (int, error)
+	// FetchArticles returns unread (archive 0) or archived (archive 1) entries
+	// having all the tags, newest first.
+	FetchArticles(ctx context.Context, page int, perPage int, archive int, tags []string) ([]WallabagEntry, error)
+	// FetchArticlesWithSince is FetchArticles limited to entries updated since
+	// the unix time, detail "metadata" omits content.
+	FetchArticlesWithSince(ctx context.Context, page int, perPage int, archive int, since int64, tags []string, detail string) ([]WallabagEntry, error)
+	FetchArticle(ctx context.Context, entryID int) (WallabagEntry, error)
+	UpdateArticle(ctx context.Context, entryID int, archive int) (WallabagEntry, error)
+	StarArticle(ctx context.Context, entryID int, starred int) (WallabagEntry, error)
+	UpdateContent(ctx context.Context, entryID int, content string) (WallabagEntry, error)
+	AddTagsToArticle(ctx context.Context, entryID int, tags []string) (WallabagEntry, error)
+	ExportArticle(ctx context.Context, entryID int, format string) (io.ReadCloser, string, error)
+	FetchTags(ctx context.Context) ([]WallabagTag, error)
+
+	FetchAnnotations(ctx context.Context, entryID int) ([]WallabagAnnotation, error)
+	AddAnnotation(ctx context.Context, entryID int, text string, quote string) (WallabagAnnotation, error)
+}
+
+var _ WallabagAPI = WallabagClient{}
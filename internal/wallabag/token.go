@@ -0,0 +1,159 @@
+package wallabag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshMargin renews access token before it expires, so requests
+// don't race with the expiry.
+const refreshMargin = time.Minute
+
+// tokenSource caches OAuth tokens of the client. It is shared by copies
+// of WallabagClient and safe for concurrent use.
+type tokenSource struct {
+	client  *http.Client
+	baseURL string
+
+	clientID     string
+	clientSecret string
+	username     string
+	password     string
+
+	mx           sync.Mutex
+	accessToken  string
+	refreshToken string
+	expires      time.Time
+	now          func() time.Time
+}
+
+func newTokenSource(client *http.Client, baseURL, clientID, clientSecret, username, password string) *tokenSource {
+	return &tokenSource{
+		client:       client,
+		baseURL:      baseURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		username:     username,
+		password:     password,
+		now:          time.Now,
+	}
+}
+
+// Token returns cached access token, renewing it with refresh token grant
+// and falling back to password grant when the refresh token is rejected.
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+
+	if ts.accessToken != "" && ts.now().Add(refreshMargin).Before(ts.expires) {
+		return ts.accessToken, nil
+	}
+	if ts.refreshToken != "" {
+		err := ts.grant(ctx, neturl.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {ts.refreshToken},
+		})
+		if err == nil {
+			return ts.accessToken, nil
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		log.Printf("Refreshing wallabag token failed, logging in again: %v", err)
+		ts.refreshToken = ""
+	}
+	err := ts.grant(ctx, neturl.Values{
+		"grant_type": {"password"},
+		"username":   {ts.username},
+		"password":   {ts.password},
+	})
+	if err != nil {
+		return "", err
+	}
+	return ts.accessToken, nil
+}
+
+// Invalidate drops access token rejected by wallabag, unless it was
+// renewed by another request already.
+func (ts *tokenSource) Invalidate(token string) {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+	if ts.accessToken == token {
+		ts.accessToken = ""
+	}
+}
+
+// grant requests tokens, credentials are sent in the form body so they
+// don't end up in access logs.
+func (ts *tokenSource) grant(ctx context.Context, params neturl.Values) error {
+	params.Set("client_id", ts.clientID)
+	params.Set("client_secret", ts.clientSecret)
+	req, err := http.NewRequestWithContext(ctx, "POST", ts.baseURL+"/oauth/v2/token", strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		// wrong credentials are reported as 400 invalid_grant
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest {
+			return fmt.Errorf("authentication failed: %w: %w", ErrUnauthorized, err)
+		}
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	var data WallabagOauthToken
+	if err := decodeResponse(resp, &data); err != nil {
+		return fmt.Errorf("failed to decode token response: %w", err)
+	}
+	ts.accessToken = data.AccessToken
+	if data.RefreshToken != "" {
+		ts.refreshToken = data.RefreshToken
+	}
+	ts.expires = ts.now().Add(time.Duration(data.ExpiresIn) * time.Second)
+	return nil
+}
+
+// do sends authorized request. Access token rejected by wallabag is
+// renewed and the request is repeated once.
+func (wc WallabagClient) do(req *http.Request) (*http.Response, error) {
+	token, err := wc.tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := wc.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+	wc.tokens.Invalidate(token)
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	token, err = wc.tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return wc.client.Do(retry)
+}
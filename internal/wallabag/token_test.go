@@ -0,0 +1,165 @@
+package wallabag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// oauthServer issues numbered tokens and records grants it served.
+type oauthServer struct {
+	mx     sync.Mutex
+	grants []string
+	issued int
+}
+
+func (s *oauthServer) serveToken(t *testing.T, rw http.ResponseWriter, req *http.Request, expiresIn int) {
+	if req.Method != "POST" || req.URL.RawQuery != "" {
+		t.Errorf("Credentials should be sent in POST body, got %s %s", req.Method, req.URL)
+	}
+	if err := req.ParseForm(); err != nil {
+		t.Fatal(err)
+	}
+	if req.PostForm.Get("client_id") != "app_xxx" || req.PostForm.Get("client_secret") != "secret_xxx" {
+		t.Errorf("Unexpected client credentials %v", req.PostForm)
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	grant := req.PostForm.Get("grant_type")
+	switch grant {
+	case "password":
+		if req.PostForm.Get("username") != "unit" || req.PostForm.Get("password") != "password" {
+			t.Errorf("Unexpected user credentials %v", req.PostForm)
+		}
+	case "refresh_token":
+		if req.PostForm.Get("refresh_token") != fmt.Sprintf("refresh_%d", s.issued) {
+			http.Error(rw, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+	}
+	s.grants = append(s.grants, grant)
+	s.issued++
+	response, _ := json.Marshal(WallabagOauthToken{
+		AccessToken:  fmt.Sprintf("access_%d", s.issued),
+		RefreshToken: fmt.Sprintf("refresh_%d", s.issued),
+		ExpiresIn:    expiresIn,
+	})
+	rw.Write(response)
+}
+
+func (s *oauthServer) Grants() []string {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return append([]string(nil), s.grants...)
+}
+
+func TestTokenSourceCachesToken(t *testing.T) {
+	oauth := &oauthServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/oauth/v2/token":
+			oauth.serveToken(t, rw, req, 3600)
+		case "/api/tags.json":
+			if req.Header.Get("Authorization") != "Bearer access_1" {
+				t.Errorf("Unexpected authorization %s", req.Header.Get("Authorization"))
+			}
+			rw.Write([]byte(`[]`))
+		default:
+			t.Errorf("Incorrect path %s", req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		// value receivers work on copies, the token still has to be shared
+		go func(wc WallabagClient) {
+			defer wg.Done()
+			if _, err := wc.FetchTags(context.Background()); err != nil {
+				t.Errorf("Unexpected error during %s", err)
+			}
+		}(wallabagClient)
+	}
+	wg.Wait()
+	if grants := oauth.Grants(); len(grants) != 1 {
+		t.Errorf("Expected a single grant, got %v", grants)
+	}
+}
+
+func TestTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	oauth := &oauthServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		oauth.serveToken(t, rw, req, 3600)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := newTokenSource(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password")
+	ts.now = func() time.Time { return now }
+
+	token, err := ts.Token(context.Background())
+	if err != nil || token != "access_1" {
+		t.Fatalf("Unexpected token %s, %v", token, err)
+	}
+	now = now.Add(59*time.Minute + 30*time.Second)
+	token, err = ts.Token(context.Background())
+	if err != nil || token != "access_2" {
+		t.Fatalf("Token should be refreshed ahead of expiry, got %s, %v", token, err)
+	}
+	// rejected refresh token falls back to password grant
+	ts.refreshToken = "revoked"
+	ts.Invalidate(token)
+	token, err = ts.Token(context.Background())
+	if err != nil || token != "access_3" {
+		t.Fatalf("Unexpected token %s, %v", token, err)
+	}
+	grants := oauth.Grants()
+	if len(grants) != 3 || grants[0] != "password" || grants[1] != "refresh_token" || grants[2] != "password" {
+		t.Errorf("Unexpected grants %v", grants)
+	}
+}
+
+func TestWallabagClientRetriesOnUnauthorized(t *testing.T) {
+	oauth := &oauthServer{}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/oauth/v2/token":
+			oauth.serveToken(t, rw, req, 3600)
+		case "/api/entries/42/tags.json":
+			calls.Add(1)
+			body, _ := io.ReadAll(req.Body)
+			if string(body) != `{"tags":"golang"}` {
+				t.Errorf("Unexpected body %s", body)
+			}
+			// the first token was revoked on the server
+			if req.Header.Get("Authorization") == "Bearer access_1" {
+				http.Error(rw, `{"error":"invalid_grant"}`, http.StatusUnauthorized)
+				return
+			}
+			response, _ := json.Marshal(WallabagEntry{ID: 42})
+			rw.Write(response)
+		default:
+			t.Errorf("Incorrect path %s", req.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	wallabagClient := NewWallabagClient(server.Client(), server.URL, "app_xxx", "secret_xxx", "unit", "password", "")
+	entry, err := wallabagClient.AddTagsToArticle(context.Background(), 42, []string{"golang"})
+	if err != nil {
+		t.Fatalf("Unexpected error during %s", err)
+	}
+	if entry.ID != 42 || calls.Load() != 2 {
+		t.Errorf("Unexpected entry %v after %d calls", entry, calls.Load())
+	}
+}
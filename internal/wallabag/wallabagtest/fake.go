@@ -0,0 +1,351 @@
+// Package wallabagtest provides an in-memory wallabag for tests of code
+// built on top of wallabag.WallabagAPI.
+package wallabagtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vanadium23/wallabag-telegram-bot/internal/wallabag"
+)
+
+// Fake keeps entries, tags and annotations in memory and follows wallabag
+// API semantics: unknown entries and pages past the last one are reported
+// as wallabag.ErrNotFound, saving a known URL returns the existing entry,
+// lists are newest first.
+type Fake struct {
+	// Now is the clock used for created, updated and archived times.
+	Now func() time.Time
+	// Err, when set, is returned by every call, as if wallabag is down.
+	Err error
+	// DefaultTags are added to created entries, like wallabag_default_tags.
+	DefaultTags []string
+
+	mx          sync.Mutex
+	entries     map[int]*wallabag.WallabagEntry
+	annotations map[int][]wallabag.WallabagAnnotation
+	tagIDs      map[string]int
+	lastID      int
+	// annotations are numbered separately from entries
+	lastAnnotationID int
+}
+
+var _ wallabag.WallabagAPI = (*Fake)(nil)
+
+func New() *Fake {
+	return &Fake{
+		Now:         time.Now,
+		entries:     map[int]*wallabag.WallabagEntry{},
+		annotations: map[int][]wallabag.WallabagAnnotation{},
+		tagIDs:      map[string]int{},
+	}
+}
+
+// Add stores the entry as is, only missing id, times and tag ids are
+// filled in. It returns the stored entry.
+func (f *Fake) Add(entry wallabag.WallabagEntry, tags ...string) wallabag.WallabagEntry {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if entry.ID == 0 {
+		entry.ID = f.lastID + 1
+	}
+	f.lastID = max(f.lastID, entry.ID)
+	now := f.Now()
+	if entry.CreatedAt == nil {
+		entry.CreatedAt = &wallabag.WallabagTime{Time: now}
+	}
+	if entry.UpdatedAt == nil {
+		entry.UpdatedAt = entry.CreatedAt
+	}
+	if entry.IsArchived != 0 && entry.ArchivedAt == nil {
+		entry.ArchivedAt = entry.UpdatedAt
+	}
+	entry.Tags = slices.Clone(entry.Tags)
+	for i := range entry.Tags {
+		entry.Tags[i] = f.tag(entry.Tags[i].Label)
+	}
+	f.addTags(&entry, tags)
+	f.entries[entry.ID] = &entry
+	return copyEntry(&entry)
+}
+
+// Entry returns the stored entry, ok is false when there is none.
+func (f *Fake) Entry(entryID int) (wallabag.WallabagEntry, bool) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	entry, ok := f.entries[entryID]
+	if !ok {
+		return wallabag.WallabagEntry{}, false
+	}
+	return copyEntry(entry), true
+}
+
+// Delete removes the entry, as if it was deleted in wallabag UI.
+func (f *Fake) Delete(entryID int) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	delete(f.entries, entryID)
+	delete(f.annotations, entryID)
+}
+
+func (f *Fake) CreateArticle(ctx context.Context, articleURL string) (wallabag.WallabagEntry, error) {
+	if err := f.check(ctx); err != nil {
+		return wallabag.WallabagEntry{}, err
+	}
+	if entryID, _ := f.EntryExists(ctx, articleURL); entryID != 0 {
+		return f.FetchArticle(ctx, entryID)
+	}
+	return f.Add(wallabag.WallabagEntry{Url: articleURL, Title: articleURL}, f.DefaultTags...), nil
+}
+
+func (f *Fake) EntryExists(ctx context.Context, articleURL string) (int, error) {
+	if err := f.check(ctx); err != nil {
+		return 0, err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	for id, entry := range f.entries {
+		if entry.Url == articleURL {
+			return id, nil
+		}
+	}
+	return 0, nil
+}
+
+func (f *Fake) FetchArticles(ctx context.Context, page int, perPage int, archive int, tags []string) ([]wallabag.WallabagEntry, error) {
+	return f.FetchArticlesWithSince(ctx, page, perPage, archive, 0, tags, "full")
+}
+
+func (f *Fake) FetchArticlesWithSince(ctx context.Context, page int, perPage int, archive int, since int64, tags []string, detail string) ([]wallabag.WallabagEntry, error) {
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	var matched []*wallabag.WallabagEntry
+	for _, entry := range f.entries {
+		if entry.IsArchived != archive || entry.UpdatedAt.Unix() < since || !hasTags(entry, tags) {
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	slices.SortFunc(matched, func(a, b *wallabag.WallabagEntry) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt.Time); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+
+	page = max(page, 1)
+	if page > 1 && (page-1)*perPage >= len(matched) {
+		return nil, f.statusError("GET", "/api/entries.json", http.StatusNotFound)
+	}
+	from := min((page-1)*perPage, len(matched))
+	to := min(page*perPage, len(matched))
+	entries := make([]wallabag.WallabagEntry, 0, to-from)
+	for _, entry := range matched[from:to] {
+		e := copyEntry(entry)
+		if detail == "metadata" {
+			e.Content = ""
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (f *Fake) FetchArticle(ctx context.Context, entryID int) (wallabag.WallabagEntry, error) {
+	return f.update(ctx, "GET", entryID, nil)
+}
+
+func (f *Fake) UpdateArticle(ctx context.Context, entryID int, archive int) (wallabag.WallabagEntry, error) {
+	return f.update(ctx, "PATCH", entryID, func(entry *wallabag.WallabagEntry, now *wallabag.WallabagTime) {
+		if entry.IsArchived == archive {
+			return
+		}
+		entry.IsArchived = archive
+		entry.ArchivedAt = nil
+		if archive != 0 {
+			entry.ArchivedAt = now
+		}
+	})
+}
+
+func (f *Fake) StarArticle(ctx context.Context, entryID int, starred int) (wallabag.WallabagEntry, error) {
+	return f.update(ctx, "PATCH", entryID, func(entry *wallabag.WallabagEntry, now *wallabag.WallabagTime) {
+		if entry.IsStarred == starred {
+			return
+		}
+		entry.IsStarred = starred
+		entry.StarredAt = nil
+		if starred != 0 {
+			entry.StarredAt = now
+		}
+	})
+}
+
+func (f *Fake) UpdateContent(ctx context.Context, entryID int, content string) (wallabag.WallabagEntry, error) {
+	return f.update(ctx, "PATCH", entryID, func(entry *wallabag.WallabagEntry, now *wallabag.WallabagTime) {
+		entry.Content = content
+	})
+}
+
+func (f *Fake) AddTagsToArticle(ctx context.Context, entryID int, tags []string) (wallabag.WallabagEntry, error) {
+	return f.update(ctx, "POST", entryID, func(entry *wallabag.WallabagEntry, now *wallabag.WallabagTime) {
+		f.addTags(entry, tags)
+	})
+}
+
+// ExportArticle renders title, url and content as text for any of
+// wallabag.ExportFormats.
+func (f *Fake) ExportArticle(ctx context.Context, entryID int, format string) (io.ReadCloser, string, error) {
+	entry, err := f.FetchArticle(ctx, entryID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !slices.Contains(wallabag.ExportFormats, format) {
+		return nil, "", f.statusError("GET", fmt.Sprintf("/api/entries/%d/export.%s", entryID, format), http.StatusBadRequest)
+	}
+	body := fmt.Sprintf("%s\n%s\n\n%s", entry.Title, entry.Url, entry.Content)
+	return io.NopCloser(strings.NewReader(body)), fmt.Sprintf("entry-%d.%s", entryID, format), nil
+}
+
+// FetchTags returns tags assigned to entries with their usage counts.
+func (f *Fake) FetchTags(ctx context.Context) ([]wallabag.WallabagTag, error) {
+	if err := f.check(ctx); err != nil {
+		return nil, err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	counts := map[string]int{}
+	for _, entry := range f.entries {
+		for _, tag := range entry.Tags {
+			counts[tag.Label]++
+		}
+	}
+	tags := make([]wallabag.WallabagTag, 0, len(counts))
+	for label, count := range counts {
+		tag := f.tag(label)
+		tag.NbEntries = count
+		tags = append(tags, tag)
+	}
+	slices.SortFunc(tags, func(a, b wallabag.WallabagTag) int {
+		return a.ID - b.ID
+	})
+	return tags, nil
+}
+
+func (f *Fake) FetchAnnotations(ctx context.Context, entryID int) ([]wallabag.WallabagAnnotation, error) {
+	if _, err := f.FetchArticle(ctx, entryID); err != nil {
+		return nil, err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return slices.Clone(f.annotations[entryID]), nil
+}
+
+func (f *Fake) AddAnnotation(ctx context.Context, entryID int, text string, quote string) (wallabag.WallabagAnnotation, error) {
+	if _, err := f.FetchArticle(ctx, entryID); err != nil {
+		return wallabag.WallabagAnnotation{}, err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	f.lastAnnotationID++
+	annotation := wallabag.WallabagAnnotation{
+		ID:     f.lastAnnotationID,
+		Text:   text,
+		Quote:  quote,
+		Ranges: []wallabag.WallabagAnnotationRange{{}},
+	}
+	f.annotations[entryID] = append(f.annotations[entryID], annotation)
+	return annotation, nil
+}
+
+// update applies change to the entry and bumps its updated time, nil
+// change only reads the entry.
+func (f *Fake) update(ctx context.Context, method string, entryID int, change func(entry *wallabag.WallabagEntry, now *wallabag.WallabagTime)) (wallabag.WallabagEntry, error) {
+	if err := f.check(ctx); err != nil {
+		return wallabag.WallabagEntry{}, err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	entry, ok := f.entries[entryID]
+	if !ok {
+		return wallabag.WallabagEntry{}, f.statusError(method, fmt.Sprintf("/api/entries/%d.json", entryID), http.StatusNotFound)
+	}
+	if change != nil {
+		now := &wallabag.WallabagTime{Time: f.Now()}
+		change(entry, now)
+		entry.UpdatedAt = now
+	}
+	return copyEntry(entry), nil
+}
+
+func (f *Fake) check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Err
+}
+
+func (f *Fake) statusError(method string, path string, status int) error {
+	return &wallabag.StatusError{
+		Method:     method,
+		URL:        "http://wallabag.test" + path,
+		StatusCode: status,
+		Body:       fmt.Sprintf(`{"error":{"code":%d,"message":"%s"}}`, status, http.StatusText(status)),
+	}
+}
+
+// tag returns the label with its id, registering new labels.
+func (f *Fake) tag(label string) wallabag.WallabagTag {
+	id, ok := f.tagIDs[label]
+	if !ok {
+		id = len(f.tagIDs) + 1
+		f.tagIDs[label] = id
+	}
+	return wallabag.WallabagTag{
+		ID:    id,
+		Label: label,
+		Slug:  strings.ReplaceAll(strings.ToLower(label), " ", "-"),
+	}
+}
+
+func (f *Fake) addTags(entry *wallabag.WallabagEntry, labels []string) {
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || hasTags(entry, []string{label}) {
+			continue
+		}
+		entry.Tags = append(entry.Tags, f.tag(label))
+	}
+}
+
+// hasTags reports whether the entry has all labels, as the tags filter
+// of wallabag does.
+func hasTags(entry *wallabag.WallabagEntry, labels []string) bool {
+	for _, label := range labels {
+		if !slices.ContainsFunc(entry.Tags, func(tag wallabag.WallabagTag) bool {
+			return tag.Label == label
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func copyEntry(entry *wallabag.WallabagEntry) wallabag.WallabagEntry {
+	e := *entry
+	e.Tags = slices.Clone(entry.Tags)
+	return e
+}